@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/csnewman/cuttle/internal/config"
+	"github.com/csnewman/cuttle/internal/generator"
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+var (
+	errMissingMapping  = errors.New("type, db_type, encode and decode are required")
+	errMappingFunction = errors.New("encode and decode must be function names")
+)
+
+// target is a set of sql inputs generating a single Go file, or a directory of files.
+type target struct {
+	inputs     []string
+	output     string
+	pkg        string
+	layout     string
+	importPath string
+	dialects   []string
+	schemas    []string
+	types      map[string]*parser.GoType
+	mappings   map[string][]*generator.TypeMapping
+}
+
+func loadConfig(path string) ([]*target, error) {
+	cfg, err := config.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]*target, 0, len(cfg.Targets))
+
+	for _, ct := range cfg.Targets {
+		t := &target{
+			inputs:     cfg.Resolve(ct.Inputs),
+			output:     cfg.Resolve([]string{ct.Output})[0],
+			pkg:        ct.Package,
+			layout:     ct.Layout,
+			importPath: ct.ImportPath,
+			dialects:   ct.Dialects,
+			schemas:    cfg.Resolve(ct.Schemas),
+			types:      make(map[string]*parser.GoType),
+			mappings:   make(map[string][]*generator.TypeMapping),
+		}
+
+		for name, raw := range ct.Types {
+			ty, err := parser.ParseGoType(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v: type %v: %w", config.ErrInvalid, path, name, err)
+			}
+
+			t.types[name] = ty
+		}
+
+		for dialect, cms := range ct.Mappings {
+			if !slices.Contains(generator.Dialects(), dialect) {
+				return nil, fmt.Errorf("%w: %v: mappings: unknown dialect %v", config.ErrInvalid, path, dialect)
+			}
+
+			for j, cm := range cms {
+				mapping, err := parseMapping(cm)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v: mapping %v of %v: %w", config.ErrInvalid, path, j, dialect, err)
+				}
+
+				t.mappings[dialect] = append(t.mappings[dialect], mapping)
+			}
+		}
+
+		targets = append(targets, t)
+	}
+
+	return targets, nil
+}
+
+func parseMapping(cm *config.Mapping) (*generator.TypeMapping, error) {
+	if cm.Type == "" || cm.DBType == "" || cm.Encode == "" || cm.Decode == "" {
+		return nil, errMissingMapping
+	}
+
+	var (
+		mapping generator.TypeMapping
+		err     error
+	)
+
+	if mapping.Type, err = parser.ParseGoType(cm.Type); err != nil {
+		return nil, fmt.Errorf("type: %w", err)
+	}
+
+	if mapping.DBType, err = parser.ParseGoType(cm.DBType); err != nil {
+		return nil, fmt.Errorf("db_type: %w", err)
+	}
+
+	if mapping.Encode, err = parser.ParseGoType(cm.Encode); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	if mapping.Decode, err = parser.ParseGoType(cm.Decode); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	// Functions are referenced as named types, such as "github.com/google/uuid.Parse"
+	if mapping.Encode.Kind != parser.GoTypeKindNamed || mapping.Decode.Kind != parser.GoTypeKindNamed {
+		return nil, fmt.Errorf("%w: %v, %v", errMappingFunction, cm.Encode, cm.Decode)
+	}
+
+	return &mapping, nil
+}
+
+func (t *target) options() generator.Options {
+	return generator.Options{
+		Package:    t.pkg,
+		Layout:     t.layout,
+		ImportPath: t.importPath,
+		Mappings:   t.mappings,
+	}
+}
+
+func expandGlobs(patterns []string) ([]string, error) {
+	var files []string
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			// Keep plain paths so that missing files are reported when opened
+			matches = []string{pattern}
+		}
+
+		files = append(files, matches...)
+	}
+
+	slices.Sort(files)
+
+	return slices.Compact(files), nil
+}
+
+func (t *target) inputFiles() ([]string, error) {
+	return expandGlobs(t.inputs)
+}
+
+func (t *target) schemaFiles() ([]string, error) {
+	return expandGlobs(t.schemas)
+}
+
+// filterDialects restricts the repositories of the unit to the dialects enabled for the target.
+func (t *target) filterDialects(unit *parser.Unit) error {
+	if len(t.dialects) == 0 {
+		return nil
+	}
+
+	for _, name := range unit.RepositoriesOrder {
+		repo := unit.Repositories[name]
+
+		repo.Dialects = slices.DeleteFunc(repo.Dialects, func(dialect string) bool {
+			return !slices.Contains(t.dialects, dialect)
+		})
+
+		if len(repo.Dialects) == 0 {
+			return fmt.Errorf("%w: repository %v supports none of the enabled dialects", config.ErrInvalid, name)
+		}
+
+		for _, query := range repo.Queries {
+			for dialect := range query.Variants {
+				if !slices.Contains(repo.Dialects, dialect) {
+					delete(query.Variants, dialect)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// replaceTypes substitutes the type overrides of the target into every arg and col.
+func (t *target) replaceTypes(unit *parser.Unit) {
+	if len(t.types) == 0 {
+		return
+	}
+
+	for _, repo := range unit.Repositories {
+		for _, query := range repo.Queries {
+			for _, arg := range query.Args {
+				arg.GoType = arg.GoType.Replace(t.types)
+			}
+
+			for _, col := range query.Cols {
+				col.GoType = col.GoType.Replace(t.types)
+			}
+		}
+	}
+}
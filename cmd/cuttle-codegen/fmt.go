@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/csnewman/cuttle/internal/diff"
+	"github.com/csnewman/cuttle/internal/format"
+)
+
+var errUnformatted = errors.New("files are not formatted")
+
+// formatFiles rewrites each file with normalised directives, printing the names of changed files. When showDiff is set,
+// the files are left untouched and a diff is printed for each file that would change instead.
+func formatFiles(files []string, opts format.Options, showDiff bool) error {
+	slices.Sort(files)
+	files = slices.Compact(files)
+
+	var (
+		errs      []error
+		unchanged = true
+	)
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		formatted, err := format.Format(src, file, opts)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if bytes.Equal(src, formatted) {
+			continue
+		}
+
+		unchanged = false
+
+		if showDiff {
+			fmt.Print(diff.Unified(file, file+" (formatted)", string(src), string(formatted)))
+
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if err := os.WriteFile(file, formatted, info.Mode().Perm()); err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		fmt.Println(file)
+	}
+
+	if showDiff && !unchanged {
+		errs = append(errs, errUnformatted)
+	}
+
+	return errors.Join(errs...)
+}
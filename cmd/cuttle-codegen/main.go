@@ -2,28 +2,111 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io/fs"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/csnewman/cuttle/internal/checker"
+	"github.com/csnewman/cuttle/internal/config"
+	"github.com/csnewman/cuttle/internal/diff"
+	"github.com/csnewman/cuttle/internal/format"
 	"github.com/csnewman/cuttle/internal/generator"
 	"github.com/csnewman/cuttle/internal/parser"
 )
 
+var (
+	errNoSchema = errors.New("no schema provided")
+	errStale    = errors.New("generated code is out of date")
+)
+
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+
+	return nil
+}
+
 func main() {
-	path := "example.sql"
+	command := "generate"
+	args := os.Args[1:]
 
-	file, err := os.Open(path)
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
+
+	var schemas stringsFlag
+
+	flags := flag.NewFlagSet("cuttle-codegen "+command, flag.ExitOnError)
+	configPath := flags.String("config", config.DefaultPath, "config file, used when -in is not provided")
+	path := flags.String("in", "", "input sql file")
+	outPath := flags.String("out", "", "output go file, or directory when using the files or packages layout")
+	pkg := flags.String("package", "", "generated go package, defaults to main")
+	layout := flags.String("layout", "", "output layout, either file, files or packages")
+	importPath := flags.String("import-path", "", "import path of the output directory, required by the packages layout")
+	flags.Var(&schemas, "schema", "schema ddl file to validate sqlite and generic queries against (repeatable)")
+	interval := flags.Duration("interval", 500*time.Millisecond, "polling interval used by watch")
+	diagFormat := flags.String("format", formatText, "diagnostic output format, either text or json")
+	showDiff := flags.Bool("d", false, "fmt: print diffs instead of rewriting files")
+	keywords := flags.String("keywords", "", "fmt: sql keyword casing, either upper or lower")
+	verbose := flags.Bool("v", false, "log debug output")
+
+	if err := flags.Parse(args); err != nil {
+		os.Exit(2)
+	}
+
+	r, err := newReporter(os.Stderr, *diagFormat)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var targets []*target
+
+	switch {
+	case command == "fmt" && flags.NArg() > 0:
+		// Files passed explicitly do not require a config
+	case *path != "":
+		if *outPath == "" && command != "fmt" {
+			fmt.Fprintln(os.Stderr, "-out is required when -in is provided")
+			os.Exit(2)
+		}
+
+		targets = []*target{{
+			inputs:     []string{*path},
+			output:     *outPath,
+			pkg:        *pkg,
+			layout:     *layout,
+			importPath: *importPath,
+			schemas:    schemas,
+		}}
+	default:
+		targets, err = loadConfig(*configPath)
+		if err != nil {
+			r.error(err)
+			os.Exit(1)
+		}
+	}
+
+	level := slog.LevelInfo
+	if *verbose {
+		level = slog.LevelDebug
 	}
-	defer file.Close()
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	// Logs are written to stderr, as stdout contains the diffs printed by check and fmt -d
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		AddSource: true,
-		Level:     slog.LevelDebug,
+		Level:     level,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			if len(groups) == 0 && a.Key == slog.SourceKey {
 				//nolint:forcetypeassert
@@ -39,26 +122,208 @@ func main() {
 		},
 	}))
 
-	unit, err := parser.Parse(file, path, logger)
-	if err != nil {
-		var el *parser.SrcError
+	var errs []error
+
+	switch command {
+	case "generate":
+		for _, t := range targets {
+			errs = append(errs, generate(t, logger, r))
+		}
+	case "check":
+		for _, t := range targets {
+			errs = append(errs, checkGenerated(t, logger, r))
+		}
+	case "watch":
+		errs = append(errs, watch(targets, logger, r, *interval))
+	case "fmt":
+		files := flags.Args()
 
-		if errors.As(err, &el) {
-			fmt.Println()
+		for _, t := range targets {
+			inputs, err := t.inputFiles()
+			if err != nil {
+				errs = append(errs, err)
 
-			for i, s := range el.Token.RawLines {
-				fmt.Printf("%v:%v: %v\n", el.Token.Source, el.Token.Start+i, s)
+				continue
 			}
 
-			fmt.Printf("%v:%v-%v: %v\n", el.Token.Source, el.Token.Start, el.Token.End, el.Inner)
+			files = append(files, inputs...)
+		}
+
+		errs = append(errs, formatFiles(files, format.Options{Keywords: *keywords}, *showDiff))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %v\n", command)
+		fmt.Fprintln(os.Stderr, "usage: cuttle-codegen [generate|check|watch|fmt] [flags] [files]")
+		os.Exit(2)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		r.error(err)
+		os.Exit(1)
+	}
+}
+
+func load(t *target, logger *slog.Logger, r *reporter) (*parser.Unit, error) {
+	inputs, err := t.inputFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	unit := &parser.Unit{
+		Repositories: make(map[string]*parser.Repository),
+	}
+
+	var errs []error
+
+	// Parse every input before failing, so that all errors are reported together
+	for _, input := range inputs {
+		parsed, err := parseFile(input, logger)
+		if parsed != nil {
+			r.warnings(parsed.Warnings)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		parsed.Warnings = nil
+
+		if err := unit.Merge(parsed); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", input, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if err := t.filterDialects(unit); err != nil {
+		return nil, err
+	}
+
+	schemas, err := t.schemaFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := check(unit, schemas, logger); err != nil {
+		return nil, err
+	}
+
+	t.replaceTypes(unit)
+
+	return unit, nil
+}
+
+func parseFile(path string, logger *slog.Logger) (*parser.Unit, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parser.Parse(file, path, logger)
+}
+
+func generate(t *target, logger *slog.Logger, r *reporter) error {
+	unit, err := load(t, logger, r)
+	if err != nil {
+		return err
+	}
+
+	return generator.Generate(unit, logger, t.output, t.options())
+}
+
+// checkGenerated regenerates the code in memory and compares it to the existing output, printing a unified diff if they
+// differ. Orphaned generated files, which generate would remove, are reported as stale.
+func checkGenerated(t *target, logger *slog.Logger, r *reporter) error {
+	unit, err := load(t, logger, r)
+	if err != nil {
+		return err
+	}
+
+	files, err := generator.Render(unit, logger, t.output, t.options())
+	if err != nil {
+		return err
+	}
+
+	// Files generated for repositories which no longer exist are stale too, and are shown as deleted
+	orphans, err := generator.Orphans(t.output, t.options(), files)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(files)+len(orphans))
 
-			return
+	for path := range files {
+		paths = append(paths, path)
+	}
+
+	paths = append(paths, orphans...)
+
+	slices.Sort(paths)
+
+	var stale []string
+
+	for _, path := range paths {
+		existing, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		d := diff.Unified(path, path+" (regenerated)", string(existing), string(files[path]))
+		if d == "" {
+			continue
 		}
 
-		panic(err)
+		fmt.Print(d)
+
+		stale = append(stale, path)
 	}
 
-	if err := generator.Generate(unit, logger, "example.gen.go"); err != nil {
-		panic(err)
+	if len(stale) > 0 {
+		return fmt.Errorf("%w: %v", errStale, strings.Join(stale, ", "))
 	}
+
+	return nil
+}
+
+func check(unit *parser.Unit, schemas []string, logger *slog.Logger) error {
+	if len(schemas) == 0 {
+		var errs []error
+
+		for _, repo := range unit.Repositories {
+			for _, query := range repo.Queries {
+				if query.Infer {
+					errs = append(errs, &parser.SrcError{
+						Token: query.Token,
+						Code:  parser.CodeNoSchema,
+						Inner: fmt.Errorf("%w: inferring columns requires a schema", errNoSchema),
+					})
+				}
+			}
+		}
+
+		return errors.Join(errs...)
+	}
+
+	c, err := checker.New(logger)
+	if err != nil {
+		return err
+	}
+
+	defer c.Close()
+
+	for _, schema := range schemas {
+		if err := c.LoadSchema(schema); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Infer(unit); err != nil {
+		return err
+	}
+
+	return c.Check(unit)
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+var errUnknownFormat = errors.New("unknown format")
+
+// reporter prints errors and warnings, either as human readable text or as one JSON object per line.
+type reporter struct {
+	w      io.Writer
+	format string
+}
+
+func newReporter(w io.Writer, format string) (*reporter, error) {
+	if format != formatText && format != formatJSON {
+		return nil, fmt.Errorf("%w: %v", errUnknownFormat, format)
+	}
+
+	return &reporter{
+		w:      w,
+		format: format,
+	}, nil
+}
+
+type jsonDiagnostic struct {
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Severity  string `json:"severity"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (r *reporter) error(err error) {
+	// Joined errors may be nested, such as the parse errors of each input of each target
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint
+		for _, err := range joined.Unwrap() {
+			r.error(err)
+		}
+
+		return
+	}
+
+	var el *parser.SrcError
+
+	if errors.As(err, &el) {
+		r.diagnostic(el)
+
+		return
+	}
+
+	if r.format == formatJSON {
+		r.writeJSON(&jsonDiagnostic{
+			Severity: parser.SeverityError.String(),
+			Message:  err.Error(),
+		})
+
+		return
+	}
+
+	fmt.Fprintf(r.w, "error: %v\n", err)
+}
+
+func (r *reporter) warnings(warnings []*parser.SrcError) {
+	for _, w := range warnings {
+		r.diagnostic(w)
+	}
+}
+
+func (r *reporter) diagnostic(err *parser.SrcError) {
+	line, col, endLine, endCol := err.Position()
+
+	file := ""
+	if err.Token != nil {
+		file = err.Token.Source
+	}
+
+	if r.format == formatJSON {
+		r.writeJSON(&jsonDiagnostic{
+			File:      file,
+			Line:      line,
+			Column:    col,
+			EndLine:   endLine,
+			EndColumn: endCol,
+			Severity:  err.Severity.String(),
+			Code:      string(err.Code),
+			Message:   err.Inner.Error(),
+		})
+
+		return
+	}
+
+	severity := err.Severity.String()
+	if err.Code != "" {
+		severity += "[" + string(err.Code) + "]"
+	}
+
+	// Errors without a token have no source to show
+	if err.Token == nil {
+		fmt.Fprintf(r.w, "%v: %v\n", severity, err.Inner)
+
+		return
+	}
+
+	fmt.Fprintf(r.w, "%v:%v:%v: %v: %v\n", file, line, col, severity, err.Inner)
+
+	gutter := len(fmt.Sprint(err.Token.End))
+
+	for i, raw := range err.Token.RawLines {
+		n := err.Token.Start + i
+
+		fmt.Fprintf(r.w, "%*d | %v\n", gutter, n, raw)
+
+		// Only single line ranges are underlined, as multi-line tokens are typically entire statements
+		if line == endLine && n == line {
+			fmt.Fprintf(r.w, "%*s | %v\n", gutter, "", underline(raw, col, endCol))
+		}
+	}
+
+	fmt.Fprintln(r.w)
+}
+
+func (r *reporter) writeJSON(d *jsonDiagnostic) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Fprintf(r.w, "%s\n", data)
+}
+
+// underline returns carets under the given columns of the line, preserving tabs so that the carets stay aligned.
+func underline(line string, start int, end int) string {
+	var sb strings.Builder
+
+	for i := 0; i < start-1 && i < len(line); i++ {
+		if line[i] == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+
+	sb.WriteString(strings.Repeat("^", max(end-start, 1)))
+
+	return sb.String()
+}
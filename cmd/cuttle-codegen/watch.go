@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"github.com/csnewman/cuttle/internal/generator"
+)
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// watch regenerates the outputs whenever the input, imported or schema files of a target change, reporting errors
+// without exiting.
+func watch(targets []*target, logger *slog.Logger, r *reporter, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := make([]map[string]fileState, len(targets))
+	// imports contains the files imported by the inputs of each target, as of the last successful load
+	imports := make([][]string, len(targets))
+
+	for {
+		for i, t := range targets {
+			state, err := watchedState(t, imports[i])
+			if err != nil {
+				return err
+			}
+
+			if maps.Equal(state, last[i]) {
+				continue
+			}
+
+			last[i] = state
+
+			unit, err := load(t, logger, r)
+			if err == nil {
+				imports[i] = unit.Imports
+
+				// Newly imported files are recorded now, rather than causing another regeneration on the next tick
+				for path, fs := range statFiles(unit.Imports) {
+					if _, ok := state[path]; !ok {
+						state[path] = fs
+					}
+				}
+
+				err = generator.Generate(unit, logger, t.output, t.options())
+			}
+
+			if err != nil {
+				r.error(err)
+			} else {
+				fmt.Printf("%v: generated %v\n", time.Now().Format(time.TimeOnly), t.output)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchedState returns the state of every file used by the target, expanding globs so that new files are detected.
+// Imported files are only known once the inputs have been parsed, so are provided by the caller.
+func watchedState(t *target, imports []string) (map[string]fileState, error) {
+	inputs, err := t.inputFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	schemas, err := t.schemaFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	return statFiles(slices.Concat(inputs, schemas, imports)), nil
+}
+
+func statFiles(files []string) map[string]fileState {
+	state := make(map[string]fileState, len(files))
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			state[file] = fileState{}
+
+			continue
+		}
+
+		state[file] = fileState{
+			exists:  true,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+	}
+
+	return state
+}
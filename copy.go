@@ -0,0 +1,56 @@
+package cuttle
+
+// CopyFromSource provides the rows of a bulk insert. It matches pgx.CopyFromSource, allowing sources to be passed
+// directly to pgx.
+type CopyFromSource interface {
+	// Next advances to the next row, returning false once no rows remain or an error occurs.
+	Next() bool
+
+	// Values returns the values of the current row.
+	Values() ([]any, error)
+
+	// Err returns any error encountered while reading rows.
+	Err() error
+}
+
+// CopyFromSlice returns a source of n rows, calling next for the values of each row in turn.
+func CopyFromSlice(n int, next func(i int) ([]any, error)) CopyFromSource {
+	return &copyFromSlice{
+		next: next,
+		idx:  -1,
+		len:  n,
+	}
+}
+
+type copyFromSlice struct {
+	next func(int) ([]any, error)
+	idx  int
+	len  int
+	err  error
+}
+
+func (s *copyFromSlice) Next() bool {
+	s.idx++
+
+	return s.idx < s.len
+}
+
+func (s *copyFromSlice) Values() ([]any, error) {
+	values, err := s.next(s.idx)
+	if err != nil {
+		s.err = err
+	}
+
+	return values, err
+}
+
+func (s *copyFromSlice) Err() error {
+	return s.err
+}
+
+// CopyFromRows returns a source of the given rows.
+func CopyFromRows(rows [][]any) CopyFromSource {
+	return CopyFromSlice(len(rows), func(i int) ([]any, error) {
+		return rows[i], nil
+	})
+}
@@ -3,9 +3,15 @@ package cuttle
 import (
 	"context"
 	"errors"
+	"io"
 )
 
-var ErrNoRows = errors.New("no rows")
+var (
+	ErrNoRows          = errors.New("no rows")
+	ErrUnsupportedType = errors.New("unsupported type")
+	// ErrReadOnlyTx is returned by stores bound to a read transaction when running a query that writes.
+	ErrReadOnlyTx = errors.New("read-only transaction")
+)
 
 type RTxFunc = func(ctx context.Context, tx RTx) error
 
@@ -37,6 +43,10 @@ type RTx interface {
 	Query(ctx context.Context, stmt string, args ...any) (Rows, error)
 
 	QueryRow(ctx context.Context, stmt string, args ...any) (Row, error)
+
+	// CopyTo streams the rows returned by query to w in the text format of the Postgres COPY command, returning the
+	// number of rows written.
+	CopyTo(ctx context.Context, query string, w io.Writer) (int64, error)
 }
 
 type WTxFuncer interface {
@@ -44,6 +54,10 @@ type WTxFuncer interface {
 
 	ExecFunc(ctx context.Context, handler TxFunc[Exec], stmt string, args ...any) error
 
+	// CopyFromFunc bulk inserts the rows of src into the columns of table, which may be qualified with a schema. The
+	// rows affected reported to the handler is the number of rows inserted.
+	CopyFromFunc(ctx context.Context, handler TxFunc[Exec], table string, columns []string, src CopyFromSource) error
+
 	DispatchBatchRW(ctx context.Context, b *BatchRW) error
 }
 
@@ -52,6 +66,10 @@ type WTx interface {
 	WTxFuncer
 
 	Exec(ctx context.Context, stmt string, args ...any) (Exec, error)
+
+	// CopyFrom bulk inserts the rows of src into the columns of table, which may be qualified with a schema, returning
+	// the number of rows inserted.
+	CopyFrom(ctx context.Context, table string, columns []string, src CopyFromSource) (int64, error)
 }
 
 type AsyncHandler[T any] func(ctx context.Context, result T, err error) error
@@ -12,6 +12,8 @@ type DialectCompatFunc func(dialect Dialect) uint
 type Dialect struct {
 	Name   string
 	Compat DialectCompatFunc
+	// ArrayArgs indicates that slices can be bound directly as array parameters.
+	ArrayArgs bool
 }
 
 func (d Dialect) Is(other Dialect) bool {
@@ -78,5 +80,6 @@ var (
 
 			return 0
 		},
+		ArrayArgs: true,
 	}
 )
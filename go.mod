@@ -7,6 +7,7 @@ require (
 	github.com/iancoleman/strcase v0.3.0
 	github.com/jackc/pgx/v5 v5.5.5
 	github.com/tailscale/sqlite v0.0.0-20240528164426-38d2414567c9
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,211 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/parser"
+	"github.com/tailscale/sqlite/cgosqlite"
+	"github.com/tailscale/sqlite/sqliteh"
+	"github.com/tailscale/sqlite/sqlitepool"
+)
+
+var ErrMismatch = errors.New("mismatch")
+
+// Checker validates queries against a scratch SQLite database loaded with a schema.
+type Checker struct {
+	logger *slog.Logger
+	db     sqliteh.DB
+}
+
+func New(logger *slog.Logger) (*Checker, error) {
+	db, err := cgosqlite.Open(":memory:", sqliteh.OpenFlagsDefault, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scratch database: %w", err)
+	}
+
+	return &Checker{
+		logger: logger,
+		db:     db,
+	}, nil
+}
+
+func (c *Checker) Close() error {
+	return c.db.Close()
+}
+
+// LoadSchema executes the DDL statements contained in the given file.
+func (c *Checker) LoadSchema(path string) error {
+	ddl, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := sqlitepool.ExecScript(c.db, string(ddl)); err != nil {
+		return fmt.Errorf("failed to load schema %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// checkedDialects contains the dialects whose statements can be prepared against SQLite, in order of preference.
+// Generic statements are expected to be portable, so are checked when a repository has no SQLite variant.
+var checkedDialects = []string{"sqlite", "generic"}
+
+// Check prepares the SQLite or generic variant of every query, ensuring the statement compiles and that the number of
+// result columns and parameters match the declared cols and args. All failures are returned as parser.SrcError values.
+func (c *Checker) Check(unit *parser.Unit) error {
+	var errs []error
+
+	for _, name := range unit.RepositoriesOrder {
+		repo := unit.Repositories[name]
+
+		if !slices.ContainsFunc(repo.Dialects, isChecked) {
+			c.logger.Info("Skipping check of repository without sqlite or generic dialect", "repo", repo.Name)
+
+			continue
+		}
+
+		for _, query := range repo.Queries {
+			// Copy queries have no sql, instead inserting into the columns of their table
+			if query.Mode == parser.ModeCopy {
+				if err := c.checkCopy(query); err != nil {
+					errs = append(errs, err)
+				}
+
+				continue
+			}
+
+			variant := checkedVariant(query)
+			if variant == nil {
+				c.logger.Info("Skipping check of query without sqlite or generic variant", "repo", repo.Name, "query", query.Name)
+
+				continue
+			}
+
+			if err := c.checkVariant(query, variant); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func isChecked(dialect string) bool {
+	return slices.Contains(checkedDialects, dialect)
+}
+
+// checkedVariant returns the variant of a query to prepare against SQLite, or nil if there is none.
+func checkedVariant(query *parser.Query) *parser.Variant {
+	for _, dialect := range checkedDialects {
+		if variant, ok := query.Variants[dialect]; ok {
+			return variant
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) checkVariant(query *parser.Query, variant *parser.Variant) error {
+	c.logger.Debug("Checking query", "name", query.Name, "dialect", variant.Name)
+
+	tk := variant.Token
+	if tk == nil {
+		tk = query.Token
+	}
+
+	stmt, _, err := c.db.Prepare(variant.Stmt, 0)
+	if err != nil {
+		return &parser.SrcError{
+			Token: tk,
+			Code:  parser.CodePrepareFailed,
+			Inner: fmt.Errorf("failed to prepare %v: %w: %v", query.Name, err, c.db.ErrMsg()),
+		}
+	}
+
+	defer stmt.Finalize()
+
+	if query.Mode != parser.ModeExec && stmt.ColumnCount() != len(query.Cols) {
+		return &parser.SrcError{
+			Token: tk,
+			Code:  parser.CodeMismatch,
+			Inner: fmt.Errorf(
+				"%w: %v returns %v columns but %v declared",
+				ErrMismatch,
+				query.Name,
+				stmt.ColumnCount(),
+				len(query.Cols),
+			),
+		}
+	}
+
+	// Args not referenced by the statement are reported as warnings by the parser, so only need to cover the parameters
+	if count, want := stmt.BindParameterCount(), referencedArgs(query, variant); count > want ||
+		(variant.Parts != nil && count != want) {
+		return &parser.SrcError{
+			Token: tk,
+			Code:  parser.CodeMismatch,
+			Inner: fmt.Errorf(
+				"%w: %v uses %v parameters but has %v args",
+				ErrMismatch,
+				query.Name,
+				count,
+				want,
+			),
+		}
+	}
+
+	return nil
+}
+
+// referencedArgs returns the number of args a variant may reference. Named parameters are counted once per distinct
+// arg, while positional parameters may reference any arg by its index.
+func referencedArgs(query *parser.Query, variant *parser.Variant) int {
+	if variant.Parts == nil {
+		return len(query.Args)
+	}
+
+	used := make(map[*parser.Arg]bool)
+
+	for _, part := range variant.Parts {
+		if part.Arg != nil {
+			used[part.Arg] = true
+		}
+	}
+
+	return len(used)
+}
+
+// checkCopy ensures the table of a copy query exists and contains a column for each arg.
+func (c *Checker) checkCopy(query *parser.Query) error {
+	c.logger.Debug("Checking copy", "name", query.Name, "table", query.Table)
+
+	columns := make([]string, 0, len(query.Args))
+	params := make([]string, 0, len(query.Args))
+
+	for _, arg := range query.Args {
+		columns = append(columns, `"`+strings.ReplaceAll(arg.Column, `"`, `""`)+`"`)
+		params = append(params, "?")
+	}
+
+	stmt, _, err := c.db.Prepare(
+		fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)", query.Table, strings.Join(columns, ", "), strings.Join(params, ", ")),
+		0,
+	)
+	if err != nil {
+		return &parser.SrcError{
+			Token: query.Token,
+			Code:  parser.CodePrepareFailed,
+			Inner: fmt.Errorf("failed to prepare %v: %w: %v", query.Name, err, c.db.ErrMsg()),
+		}
+	}
+
+	stmt.Finalize()
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package checker
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+func TestCheckParams(t *testing.T) {
+	c := newTestChecker(t, `CREATE TABLE users (id INTEGER PRIMARY KEY, role TEXT);`)
+
+	tests := []struct {
+		name    string
+		src     string
+		invalid bool
+	}{
+		{
+			name: "named",
+			src:  "-- :arg name=id type=int64\n-- :arg name=role type=string\nDELETE FROM users WHERE id = :id OR role = :role;",
+		},
+		{
+			name: "named reused",
+			src:  "-- :arg name=id type=int64\nDELETE FROM users WHERE id = :id OR id = -:id;",
+		},
+		{
+			name: "named unused",
+			src:  "-- :arg name=id type=int64\n-- :arg name=role type=string\nDELETE FROM users WHERE id = :id;",
+		},
+		{
+			name: "positional unused",
+			src:  "-- :arg name=id type=int64\n-- :arg name=role type=string\nDELETE FROM users WHERE id = ?1;",
+		},
+		{
+			name:    "positional missing",
+			src:     "-- :arg name=id type=int64\nDELETE FROM users WHERE id = ?1 OR role = ?2;",
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "-- :cuttle version=1\n-- :repository name=UsersRepository dialects=sqlite\n" +
+				"-- :query name=Delete mode=exec\n" + tt.src + "\n"
+
+			unit, err := parser.Parse(strings.NewReader(src), "users.sql", slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = c.Check(unit)
+			if got := errors.Is(err, ErrMismatch); got != tt.invalid {
+				t.Errorf("Check() = %v, want mismatch %v", err, tt.invalid)
+			}
+		})
+	}
+}
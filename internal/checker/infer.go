@@ -0,0 +1,210 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/parser"
+	"github.com/tailscale/sqlite/sqliteh"
+)
+
+var ErrInferFailed = errors.New("unable to infer column")
+
+// Infer derives the columns of queries marked with infer=true from their SQLite or generic variant, using the declared
+// type of each result column. Explicitly declared columns override the inferred column with the same name.
+func (c *Checker) Infer(unit *parser.Unit) error {
+	var errs []error
+
+	for _, name := range unit.RepositoriesOrder {
+		repo := unit.Repositories[name]
+
+		for _, query := range repo.Queries {
+			if !query.Infer {
+				continue
+			}
+
+			if err := c.inferQuery(query); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Checker) inferQuery(query *parser.Query) error {
+	c.logger.Debug("Inferring columns", "name", query.Name)
+
+	variant := checkedVariant(query)
+	if variant == nil {
+		return &parser.SrcError{
+			Token: query.Token,
+			Code:  parser.CodeInferFailed,
+			Inner: fmt.Errorf("%w: %v has no sqlite or generic variant", ErrInferFailed, query.Name),
+		}
+	}
+
+	tk := variant.Token
+	if tk == nil {
+		tk = query.Token
+	}
+
+	stmt, _, err := c.db.Prepare(variant.Stmt, 0)
+	if err != nil {
+		return &parser.SrcError{
+			Token: tk,
+			Code:  parser.CodePrepareFailed,
+			Inner: fmt.Errorf("failed to prepare %v: %w: %v", query.Name, err, c.db.ErrMsg()),
+		}
+	}
+
+	defer stmt.Finalize()
+
+	overrides := make(map[string]*parser.Col)
+
+	for _, col := range query.Cols {
+		overrides[col.Name] = col
+	}
+
+	cols := make([]*parser.Col, 0, stmt.ColumnCount())
+	origins := originNames(variant.Stmt, stmt.ColumnCount())
+
+	for i := range stmt.ColumnCount() {
+		name := stmt.ColumnName(i)
+
+		if col, ok := overrides[name]; ok {
+			cols = append(cols, col)
+			delete(overrides, name)
+
+			continue
+		}
+
+		// Without the result columns, such as when selecting a wildcard, columns are assumed to be unaliased
+		origin := name
+		if origins != nil {
+			origin = origins[i]
+		}
+
+		col, err := c.inferCol(stmt, i, origin)
+		if err != nil {
+			return &parser.SrcError{
+				Token: tk,
+				Code:  parser.CodeInferFailed,
+				Inner: fmt.Errorf("%v: %w", query.Name, err),
+			}
+		}
+
+		cols = append(cols, col)
+	}
+
+	// Report the first unmatched override in declaration order
+	for _, col := range query.Cols {
+		if _, ok := overrides[col.Name]; !ok {
+			continue
+		}
+
+		return &parser.SrcError{
+			Token: col.Token,
+			Code:  parser.CodeInferFailed,
+			Inner: fmt.Errorf("%w: %v does not return column %v", ErrInferFailed, query.Name, col.Name),
+		}
+	}
+
+	if len(cols) == 0 {
+		return &parser.SrcError{
+			Token: tk,
+			Code:  parser.CodeInferFailed,
+			Inner: fmt.Errorf("%w: %v returns no columns", ErrInferFailed, query.Name),
+		}
+	}
+
+	query.Cols = cols
+
+	return nil
+}
+
+// inferCol infers result column i of stmt, where origin is the name of the table column it reads.
+func (c *Checker) inferCol(stmt sqliteh.Stmt, i int, origin string) (*parser.Col, error) {
+	name := stmt.ColumnName(i)
+
+	if !token.IsIdentifier(name) {
+		return nil, fmt.Errorf("%w %v: invalid name %q, add an alias", ErrInferFailed, i, name)
+	}
+
+	declType := stmt.ColumnDeclType(i)
+
+	ty := goTypeForDecl(declType)
+	if ty == "" {
+		return nil, fmt.Errorf("%w %v: ambiguous type %q, add a :col override", ErrInferFailed, name, declType)
+	}
+
+	goType, err := parser.ParseGoType(ty)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parser.Col{
+		Name:     name,
+		Type:     ty,
+		GoType:   goType,
+		Nullable: !c.notNull(stmt.ColumnTableName(i), origin),
+	}, nil
+}
+
+// notNull reports whether the column is known to be NOT NULL. Columns that can not be traced back to a table column,
+// such as expressions, are assumed to be nullable.
+func (c *Checker) notNull(table string, column string) bool {
+	if table == "" || column == "" {
+		return false
+	}
+
+	stmt, _, err := c.db.Prepare("SELECT type, \"notnull\", pk FROM pragma_table_info(?1) WHERE name = ?2", 0)
+	if err != nil {
+		c.logger.Warn("Failed to query table info", "table", table, "err", err)
+
+		return false
+	}
+
+	defer stmt.Finalize()
+
+	if err := stmt.BindText64(1, table); err != nil {
+		return false
+	}
+
+	if err := stmt.BindText64(2, column); err != nil {
+		return false
+	}
+
+	row, err := stmt.Step(nil)
+	if err != nil || !row {
+		return false
+	}
+
+	// INTEGER PRIMARY KEY columns alias the rowid, which can never be NULL
+	return stmt.ColumnInt64(1) != 0 || (stmt.ColumnInt64(2) != 0 && strings.EqualFold(stmt.ColumnText(0), "INTEGER"))
+}
+
+// goTypeForDecl maps a declared SQLite column type to a Go type, following the SQLite type affinity rules. An empty
+// string is returned when the type is ambiguous.
+func goTypeForDecl(declType string) string {
+	decl := strings.ToUpper(declType)
+
+	switch {
+	case decl == "":
+		return ""
+	case strings.Contains(decl, "BOOL"):
+		return "bool"
+	case strings.Contains(decl, "INT"):
+		return "int64"
+	case strings.Contains(decl, "CHAR"), strings.Contains(decl, "CLOB"), strings.Contains(decl, "TEXT"):
+		return "string"
+	case strings.Contains(decl, "BLOB"):
+		return "[]byte"
+	case strings.Contains(decl, "REAL"), strings.Contains(decl, "FLOA"), strings.Contains(decl, "DOUB"):
+		return "float64"
+	default:
+		return ""
+	}
+}
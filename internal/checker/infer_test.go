@@ -0,0 +1,136 @@
+package checker
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+func newTestChecker(t *testing.T, ddl string) *Checker {
+	t.Helper()
+
+	c, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		c.Close()
+	})
+
+	path := filepath.Join(t.TempDir(), "schema.sql")
+
+	if err := os.WriteFile(path, []byte(ddl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.LoadSchema(path); err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestInferNullability(t *testing.T) {
+	c := newTestChecker(t, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, username TEXT NOT NULL, email TEXT);
+	`)
+
+	query := &parser.Query{
+		Name:  "Get",
+		Mode:  parser.ModeQueryRow,
+		Infer: true,
+		Variants: map[string]*parser.Variant{
+			"generic": {
+				Name: "generic",
+				Stmt: "SELECT u.id AS user_id, u.username AS name, u.email, u.username, e.email AS other " +
+					"FROM users u JOIN users e ON e.id = u.id",
+			},
+		},
+	}
+
+	if err := c.inferQuery(query); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name     string
+		nullable bool
+	}{
+		{name: "user_id", nullable: false},
+		{name: "name", nullable: false},
+		{name: "email", nullable: true},
+		{name: "username", nullable: false},
+		{name: "other", nullable: true},
+	}
+
+	if len(query.Cols) != len(want) {
+		t.Fatalf("inferred %v columns, want %v", len(query.Cols), len(want))
+	}
+
+	for i, col := range query.Cols {
+		if col.Name != want[i].name || col.Nullable != want[i].nullable {
+			t.Errorf("column %v = %v nullable=%v, want %v nullable=%v",
+				i, col.Name, col.Nullable, want[i].name, want[i].nullable)
+		}
+	}
+}
+
+func TestOriginNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		stmt  string
+		count int
+		want  []string
+	}{
+		{
+			name:  "aliases",
+			stmt:  `SELECT DISTINCT id AS user_id, u.name n, main.u."e""x", ["b"] FROM u`,
+			count: 4,
+			want:  []string{"id", "name", `e"x`, ""},
+		},
+		{
+			name:  "expressions",
+			stmt:  "SELECT count(*), a + b AS c, ?1 AS d, x COLLATE nocase, CAST(y AS TEXT) z FROM t",
+			count: 5,
+			want:  []string{"", "", "", "", ""},
+		},
+		{
+			name:  "common table expression",
+			stmt:  "WITH c AS (SELECT a, b FROM t) SELECT b AS x /* , y */ FROM c UNION SELECT 1",
+			count: 1,
+			want:  []string{"b"},
+		},
+		{
+			name:  "returning",
+			stmt:  "INSERT INTO t (a) SELECT a FROM s RETURNING id AS i, a",
+			count: 2,
+			want:  []string{"id", "a"},
+		},
+		{
+			name:  "wildcard",
+			stmt:  "SELECT u.*, id AS x FROM u",
+			count: 3,
+			want:  nil,
+		},
+		{
+			name:  "count mismatch",
+			stmt:  "SELECT a, b FROM t",
+			count: 3,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := originNames(tt.stmt, tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("originNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
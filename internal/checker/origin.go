@@ -0,0 +1,189 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/sqlscan"
+)
+
+// resultEnd contains the keywords ending the result columns of a SELECT statement.
+var resultEnd = map[string]bool{
+	"FROM":      true,
+	"WHERE":     true,
+	"GROUP":     true,
+	"HAVING":    true,
+	"WINDOW":    true,
+	"ORDER":     true,
+	"LIMIT":     true,
+	"UNION":     true,
+	"INTERSECT": true,
+	"EXCEPT":    true,
+}
+
+// originNames returns the name of the table column each result column of stmt reads, which differs from the result
+// column name when aliased. An empty string is returned for expressions. Nil is returned when the result columns can
+// not be matched against the prepared statement, such as when they contain a wildcard.
+//
+// The result columns are read from the RETURNING clause, or otherwise the first SELECT outside of parentheses.
+func originNames(stmt string, count int) []string {
+	var (
+		items [][]sqlscan.Token
+		item  []sqlscan.Token
+		depth int
+		in    bool
+	)
+
+	for _, tk := range sqlscan.Scan(stmt) {
+		switch tk.Kind {
+		case sqlscan.KindComment:
+			continue
+
+		case sqlscan.KindWord:
+			word := strings.ToUpper(tk.Text)
+
+			if depth == 0 && word == "RETURNING" {
+				items, item, in = nil, nil, true
+
+				continue
+			}
+
+			if depth == 0 && word == "SELECT" && items == nil && item == nil && !in {
+				in = true
+
+				continue
+			}
+
+			if depth == 0 && in && resultEnd[word] {
+				in = false
+			}
+
+			if depth == 0 && in && item == nil && (word == "DISTINCT" || word == "ALL") {
+				continue
+			}
+
+		case sqlscan.KindText:
+			for _, c := range tk.Text {
+				switch c {
+				case '(', '[':
+					depth++
+				case ')', ']':
+					depth = max(depth-1, 0)
+				case ',':
+					if depth == 0 && in {
+						items = append(items, item)
+						item = []sqlscan.Token{}
+					}
+
+					continue
+				case ';':
+					if depth == 0 {
+						in = false
+					}
+				}
+
+				if in && c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+					item = append(item, sqlscan.Token{Kind: sqlscan.KindText, Text: string(c)})
+				}
+			}
+
+			continue
+		}
+
+		if !in {
+			continue
+		}
+
+		// Escaped quotes, such as "a""b", are scanned as adjacent quoted tokens
+		if n := len(item); n > 0 && tk.Kind == sqlscan.KindQuoted && item[n-1].Kind == sqlscan.KindQuoted &&
+			item[n-1].End == tk.Start && item[n-1].Text[0] == tk.Text[0] {
+			item[n-1].Text += tk.Text
+			item[n-1].End = tk.End
+
+			continue
+		}
+
+		item = append(item, tk)
+	}
+
+	if item != nil {
+		items = append(items, item)
+	}
+
+	if len(items) != count {
+		return nil
+	}
+
+	names := make([]string, len(items))
+
+	for i, item := range items {
+		name, ok := originName(item)
+		if !ok {
+			return nil
+		}
+
+		names[i] = name
+	}
+
+	return names
+}
+
+// originName returns the column read by a result column of the form "[[schema.]table.]column [[AS] alias]", or an
+// empty string for other expressions. False is returned for wildcards.
+func originName(item []sqlscan.Token) (string, bool) {
+	// Strip the alias
+	if n := len(item); n >= 2 && isIdent(item[n-1]) {
+		if strings.EqualFold(item[n-2].Text, "AS") {
+			item = item[:n-2]
+		} else if isIdent(item[n-2]) {
+			item = item[:n-1]
+		}
+	}
+
+	var name string
+
+	for i, tk := range item {
+		if i%2 == 1 {
+			if tk.Text != "." {
+				return "", true
+			}
+
+			continue
+		}
+
+		if tk.Text == "*" {
+			return "", false
+		}
+
+		if !isIdent(tk) {
+			return "", true
+		}
+
+		name = tk.Text
+	}
+
+	if len(item)%2 == 0 {
+		return "", true
+	}
+
+	if strings.HasPrefix(name, `"`) || strings.HasPrefix(name, "`") {
+		quote := name[:1]
+		name = strings.ReplaceAll(strings.TrimSuffix(name[1:], quote), quote+quote, quote)
+	}
+
+	return name, true
+}
+
+func isIdent(tk sqlscan.Token) bool {
+	switch tk.Kind {
+	case sqlscan.KindWord:
+		return !isDigit(tk.Text[0])
+	case sqlscan.KindQuoted:
+		return strings.HasPrefix(tk.Text, `"`) || strings.HasPrefix(tk.Text, "`")
+	default:
+		return false
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the config file read from the working directory, or the root of the workspace.
+const DefaultPath = "cuttle.yaml"
+
+var ErrInvalid = errors.New("invalid config")
+
+type Config struct {
+	Version int       `yaml:"version"`
+	Targets []*Target `yaml:"targets"`
+	// dir is the directory containing the config file, which paths are relative to
+	dir string
+}
+
+type Target struct {
+	// Inputs contains glob patterns matching the sql files of the target.
+	Inputs []string `yaml:"inputs"`
+	// Output is the generated Go file, or a directory when repositories are generated into separate files or packages.
+	Output string `yaml:"output"`
+	// Package is the name of the generated Go package.
+	Package string `yaml:"package"`
+	// Layout is either file, files or packages, controlling how repositories are split between files.
+	Layout string `yaml:"layout"`
+	// ImportPath is the import path of the output directory, required by the packages layout.
+	ImportPath string `yaml:"import_path"`
+	// Dialects restricts generation to a subset of the dialects supported by each repository.
+	Dialects []string `yaml:"dialects"`
+	// Schemas contains glob patterns matching the ddl files used to validate queries.
+	Schemas []string `yaml:"schemas"`
+	// Types maps type names used in :arg and :col directives to Go types.
+	Types map[string]string `yaml:"types"`
+	// Mappings contains the type mappings of each dialect, converting Go types unsupported by its driver.
+	Mappings map[string][]*Mapping `yaml:"mappings"`
+}
+
+type Mapping struct {
+	// Type is the Go type used by the generated methods.
+	Type string `yaml:"type"`
+	// DBType is the Go type bound and scanned by the driver.
+	DBType string `yaml:"db_type"`
+	// Encode is a function converting Type to DBType.
+	Encode string `yaml:"encode"`
+	// Decode is a function converting DBType to Type, returning an error.
+	Decode string `yaml:"decode"`
+}
+
+// Read parses the config file at path, checking its version and that every target has inputs and an output.
+func Read(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		dir: filepath.Dir(path),
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %w", path, err)
+	}
+
+	if cfg.Version != 1 {
+		return nil, fmt.Errorf("%w: %v: unsupported version %v", ErrInvalid, path, cfg.Version)
+	}
+
+	if len(cfg.Targets) == 0 {
+		return nil, fmt.Errorf("%w: %v: no targets defined", ErrInvalid, path)
+	}
+
+	for i, t := range cfg.Targets {
+		if len(t.Inputs) == 0 || t.Output == "" {
+			return nil, fmt.Errorf("%w: %v: target %v requires inputs and an output", ErrInvalid, path, i)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Resolve returns the paths relative to the directory of the config file.
+func (c *Config) Resolve(paths []string) []string {
+	resolved := make([]string, len(paths))
+
+	for i, p := range paths {
+		if filepath.IsAbs(p) {
+			resolved[i] = p
+		} else {
+			resolved[i] = filepath.Join(c.dir, p)
+		}
+	}
+
+	return resolved
+}
+
+// TargetOf returns the first target whose inputs match the sql file at path, or nil if there is none.
+func (c *Config) TargetOf(path string) *Target {
+	for _, t := range c.Targets {
+		for _, pattern := range c.Resolve(t.Inputs) {
+			if ok, _ := filepath.Match(pattern, path); ok {
+				return t
+			}
+		}
+	}
+
+	return nil
+}
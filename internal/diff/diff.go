@@ -0,0 +1,298 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	// a and b are the line indexes in the old and new text respectively
+	a int
+	b int
+}
+
+// Unified returns a unified diff between two texts, or an empty string if they are equal.
+func Unified(oldName string, newName string, oldText string, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	ops := myers(a, b)
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "--- %v\n+++ %v\n", oldName, newName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk until the changes are separated by enough unchanged lines
+		end := start
+		equalRun := 0
+
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind == opEqual {
+				equalRun++
+
+				if equalRun > contextLines*2 {
+					break
+				}
+
+				continue
+			}
+
+			equalRun = 0
+			end = i + 1
+		}
+
+		hunkStart := max(start-contextLines, 0)
+		hunkEnd := min(end+contextLines, len(ops))
+
+		writeHunk(&sb, ops[hunkStart:hunkEnd], a, b)
+
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, ops []op, a []string, b []string) {
+	var (
+		aCount int
+		bCount int
+	)
+
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			aCount++
+			bCount++
+		case opDelete:
+			aCount++
+		case opInsert:
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%v +%v @@\n", hunkRange(ops[0].a, aCount), hunkRange(ops[0].b, bCount))
+
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			sb.WriteString(" " + a[o.a])
+		case opDelete:
+			sb.WriteString("-" + a[o.a])
+		case opInsert:
+			sb.WriteString("+" + b[o.b])
+		}
+	}
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%v,0", start)
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%v", start+1)
+	}
+
+	return fmt.Sprintf("%v,%v", start+1, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	}
+
+	return lines
+}
+
+// myers computes an edit script between a and b. Common leading and trailing lines are matched directly, and the
+// remaining lines are diffed using the Myers diff algorithm. When they differ by more than maxEditDistance lines, the
+// remaining lines are replaced as a whole instead, bounding the memory used to O(maxEditDistance²).
+func myers(a []string, b []string) []op {
+	prefix := 0
+
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+
+	for i := range prefix {
+		ops = append(ops, op{kind: opEqual, a: i, b: i})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	mid, ok := shortestEdit(midA, midB)
+	if !ok {
+		mid = replaceAll(midA, midB)
+	}
+
+	for _, o := range mid {
+		ops = append(ops, op{kind: o.kind, a: o.a + prefix, b: o.b + prefix})
+	}
+
+	for i := range suffix {
+		ops = append(ops, op{kind: opEqual, a: len(a) - suffix + i, b: len(b) - suffix + i})
+	}
+
+	return ops
+}
+
+// maxEditDistance is the largest number of inserted and deleted lines searched for by shortestEdit.
+const maxEditDistance = 1000
+
+// replaceAll returns an edit script deleting all of a, then inserting all of b.
+func replaceAll(a []string, b []string) []op {
+	ops := make([]op, 0, len(a)+len(b))
+
+	for i := range a {
+		ops = append(ops, op{kind: opDelete, a: i, b: 0})
+	}
+
+	for i := range b {
+		ops = append(ops, op{kind: opInsert, a: len(a), b: i})
+	}
+
+	return ops
+}
+
+// shortestEdit computes the shortest edit script between a and b using the Myers diff algorithm. False is returned
+// when the script is longer than maxEditDistance.
+func shortestEdit(a []string, b []string) ([]op, bool) {
+	n := len(a)
+	m := len(b)
+
+	if n == 0 || m == 0 {
+		return replaceAll(a, b), true
+	}
+
+	maxD := min(n+m, maxEditDistance)
+	offset := maxD + 1
+
+	v := make([]int, 2*maxD+3)
+
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		// Only the diagonals reachable at this depth are needed when backtracking
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, d), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func backtrack(trace [][]int, a []string, b []string, d int) []op {
+	x := len(a)
+	y := len(b)
+
+	var ops []op
+
+	for ; d > 0; d-- {
+		v := trace[d]
+		base := d + 1
+		k := x - y
+
+		var prevK int
+
+		if k == -d || (k != d && v[base+k-1] < v[base+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[base+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+
+			ops = append(ops, op{kind: opEqual, a: x, b: y})
+		}
+
+		if x == prevX {
+			y--
+
+			ops = append(ops, op{kind: opInsert, a: x, b: y})
+		} else {
+			x--
+
+			ops = append(ops, op{kind: opDelete, a: x, b: y})
+		}
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+
+		ops = append(ops, op{kind: opEqual, a: x, b: y})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
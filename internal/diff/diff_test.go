@@ -0,0 +1,184 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    string
+	}{
+		{
+			name:    "equal",
+			oldText: "a\nb\n",
+			newText: "a\nb\n",
+			want:    "",
+		},
+		{
+			name:    "change",
+			oldText: "a\nb\nc\n",
+			newText: "a\nB\nc\n",
+			want:    "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:    "from empty",
+			oldText: "",
+			newText: "a\n",
+			want:    "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:    "to empty",
+			oldText: "a\nb\n",
+			newText: "",
+			want:    "--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:    "missing newline",
+			oldText: "a\nb",
+			newText: "a\nb\n",
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:    "separate hunks",
+			oldText: lines(1, 20),
+			newText: strings.Replace(strings.Replace(lines(1, 20), "2\n", "two\n", 1), "19\n", "nineteen\n", 1),
+			want: "--- old\n+++ new\n" +
+				"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -16,5 +16,5 @@\n 16\n 17\n 18\n-19\n+nineteen\n 20\n",
+		},
+		{
+			name:    "merged hunks",
+			oldText: lines(1, 10),
+			newText: strings.Replace(strings.Replace(lines(1, 10), "2\n", "two\n", 1), "8\n", "eight\n", 1),
+			want: "--- old\n+++ new\n" +
+				"@@ -1,10 +1,10 @@\n 1\n-2\n+two\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n 9\n 10\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("old", "new", tt.oldText, tt.newText)
+			if got != tt.want {
+				t.Errorf("Unified() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedApplies(t *testing.T) {
+	tests := []struct {
+		oldText string
+		newText string
+	}{
+		{oldText: "a\nb\nc\n", newText: "c\nb\na\n"},
+		{oldText: lines(1, 30), newText: lines(5, 40)},
+		{oldText: "x\n" + lines(1, 15) + "y\n", newText: lines(1, 15)},
+		{oldText: "a\na\na\nb\n", newText: "b\na\na\na\n"},
+		{oldText: "", newText: lines(1, 5)},
+		{oldText: lines(1, 3000), newText: lines(1, 10) + lines(1000, 2000) + lines(2990, 3000)},
+	}
+
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			patch := Unified("old", "new", tt.oldText, tt.newText)
+
+			got, err := apply(tt.oldText, patch)
+			if err != nil {
+				t.Fatalf("failed to apply %q: %v", patch, err)
+			}
+
+			if got != tt.newText {
+				t.Errorf("applying the diff produced %q, want %q", got, tt.newText)
+			}
+		})
+	}
+}
+
+func TestUnifiedReplace(t *testing.T) {
+	// The changed lines differ by more than maxEditDistance lines, so are replaced as a whole
+	oldText := "a\n" + lines(1, 600) + "z\n"
+	newText := "a\n" + lines(601, 1200) + "z\n"
+
+	patch := Unified("old", "new", oldText, newText)
+
+	header := "--- old\n+++ new\n@@ -1,602 +1,602 @@\n a\n-1\n-2\n"
+	if !strings.HasPrefix(patch, header) {
+		t.Errorf("Unified() = %q..., want prefix %q", patch[:min(len(patch), len(header))], header)
+	}
+
+	if !strings.Contains(patch, "-600\n+601\n") {
+		t.Errorf("Unified() does not replace the changed lines as a whole")
+	}
+
+	got, err := apply(oldText, patch)
+	if err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+
+	if got != newText {
+		t.Errorf("applying the diff produced a different text")
+	}
+}
+
+func lines(from int, to int) string {
+	var sb strings.Builder
+
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(&sb, "%v\n", i)
+	}
+
+	return sb.String()
+}
+
+// apply applies a unified diff to text, checking that context and deleted lines match.
+func apply(text string, patch string) (string, error) {
+	src := splitLines(text)
+	patchLines := strings.SplitAfter(patch, "\n")
+
+	var (
+		out []string
+		pos int
+	)
+
+	for _, line := range patchLines[2:] {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "@@"):
+			var oldStart int
+
+			if _, err := fmt.Sscanf(line, "@@ -%d", &oldStart); err != nil {
+				return "", err
+			}
+
+			// Empty ranges refer to the line before the change
+			if !strings.HasPrefix(line, fmt.Sprintf("@@ -%v,0 ", oldStart)) {
+				oldStart--
+			}
+
+			out = append(out, src[pos:oldStart]...)
+			pos = oldStart
+		case line[0] == ' ' || line[0] == '-':
+			if pos >= len(src) || src[pos] != line[1:] {
+				return "", fmt.Errorf("line %v does not match %q", pos+1, line)
+			}
+
+			if line[0] == ' ' {
+				out = append(out, src[pos])
+			}
+
+			pos++
+		case line[0] == '+':
+			out = append(out, line[1:])
+		}
+	}
+
+	out = append(out, src[pos:]...)
+
+	return strings.Join(out, ""), nil
+}
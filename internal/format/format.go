@@ -0,0 +1,251 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+var ErrInvalidKeywordCase = errors.New("invalid keyword case")
+
+const (
+	KeywordsPreserve = ""
+	KeywordsUpper    = "upper"
+	KeywordsLower    = "lower"
+)
+
+type Options struct {
+	// Keywords sets the casing of SQL keywords, either KeywordsUpper, KeywordsLower or KeywordsPreserve.
+	Keywords string
+}
+
+type lineKind int
+
+const (
+	lineBlank lineKind = iota
+	lineComment
+	lineSQL
+	lineDoc
+	lineDirective
+)
+
+type line struct {
+	kind lineKind
+	text string
+	dir  parser.DirectiveType
+}
+
+// Format re-emits a cuttle sql file with normalised directives and a single blank line before each query, repository,
+// migration and fragment. Comments and doc blocks are preserved as written, as is the line ending of the file.
+func Format(src []byte, file string, opts Options) ([]byte, error) {
+	if opts.Keywords != KeywordsPreserve && opts.Keywords != KeywordsUpper && opts.Keywords != KeywordsLower {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeywordCase, opts.Keywords)
+	}
+
+	tz := parser.NewTokenizer(bytes.NewReader(src), file)
+
+	var (
+		lines []line
+		inDoc bool
+	)
+
+	for {
+		tk, err := tz.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if tk.Type == parser.TokenTypeDirective {
+			dir, err := tk.ParseDirective()
+			if err != nil {
+				return nil, err
+			}
+
+			switch dir.Type {
+			case parser.DirectiveTypeDoc:
+				inDoc = true
+			case parser.DirectiveTypeEnd:
+				inDoc = false
+			}
+
+			lines = appendDirective(lines, dir)
+
+			continue
+		}
+
+		if inDoc {
+			for _, raw := range tk.RawLines {
+				lines = append(lines, line{
+					kind: lineDoc,
+					text: raw,
+				})
+			}
+
+			continue
+		}
+
+		text := strings.Join(tk.RawLines, "\n")
+
+		if opts.Keywords != KeywordsPreserve {
+			text = caseKeywords(text, opts.Keywords == KeywordsUpper)
+		}
+
+		for _, raw := range strings.Split(text, "\n") {
+			trimmed := strings.TrimSpace(raw)
+
+			switch {
+			case trimmed == "":
+				lines = append(lines, line{kind: lineBlank})
+			case strings.HasPrefix(trimmed, "--"):
+				lines = append(lines, line{kind: lineComment, text: raw})
+			default:
+				lines = append(lines, line{kind: lineSQL, text: raw})
+			}
+		}
+	}
+
+	lines = trimBlank(lines)
+
+	// The tokenizer strips line endings, so the ending of the first line is used throughout
+	newline := "\n"
+	if i := bytes.IndexByte(src, '\n'); i > 0 && src[i-1] == '\r' {
+		newline = "\r\n"
+	}
+
+	var buf bytes.Buffer
+
+	for _, l := range lines {
+		buf.WriteString(l.text)
+		buf.WriteString(newline)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// appendDirective adds a formatted directive, separating queries, repositories, migrations and fragments from the
+// preceding content by a single blank line.
+func appendDirective(lines []line, dir *parser.Directive) []line {
+	formatted := line{
+		kind: lineDirective,
+		text: formatDirective(dir),
+		dir:  dir.Type,
+	}
+
+	if dir.Type != parser.DirectiveTypeQuery &&
+		dir.Type != parser.DirectiveTypeRepository &&
+		dir.Type != parser.DirectiveTypeMigration &&
+		dir.Type != parser.DirectiveTypeFragment {
+		return append(lines, formatted)
+	}
+
+	trimmed := trimBlank(lines)
+	hadBlank := len(trimmed) != len(lines)
+
+	// Comments directly above the directive describe it, so the blank line is placed above them instead
+	start := len(trimmed)
+	for start > 0 && trimmed[start-1].kind == lineComment {
+		start--
+	}
+
+	if start < len(trimmed) && !hadBlank {
+		comments := slices.Clone(trimmed[start:])
+		trimmed = separate(trimBlank(trimmed[:start]))
+
+		return append(append(trimmed, comments...), formatted)
+	}
+
+	if hadBlank {
+		trimmed = append(trimmed, line{kind: lineBlank})
+	} else {
+		trimmed = separate(trimmed)
+	}
+
+	return append(trimmed, formatted)
+}
+
+// separate appends a blank line, unless the lines are empty or end with the cuttle header, which may be directly
+// followed by the first repository.
+func separate(lines []line) []line {
+	if len(lines) == 0 {
+		return lines
+	}
+
+	prev := lines[len(lines)-1]
+	if prev.kind == lineDirective && prev.dir == parser.DirectiveTypeCuttle {
+		return lines
+	}
+
+	return append(lines, line{kind: lineBlank})
+}
+
+func trimBlank(lines []line) []line {
+	for len(lines) > 0 && lines[len(lines)-1].kind == lineBlank {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// formatDirective emits a directive with its keys in canonical order, followed by any unknown keys in alphabetical
+// order.
+func formatDirective(dir *parser.Directive) string {
+	var sb strings.Builder
+
+	sb.WriteString("-- :")
+	sb.WriteString(string(dir.Type))
+
+	known := parser.DirectiveKeys[dir.Type]
+
+	keys := make([]string, 0, len(dir.Values))
+
+	for key := range dir.Values {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a string, b string) int {
+		ai := slices.Index(known, a)
+		bi := slices.Index(known, b)
+
+		switch {
+		case ai != -1 && bi != -1:
+			return ai - bi
+		case ai != -1:
+			return -1
+		case bi != -1:
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	})
+
+	for _, key := range keys {
+		sb.WriteString(" ")
+		sb.WriteString(key)
+
+		// Keys without a value are emitted bare, as they are parsed with an empty value
+		if value := dir.Values[key]; value != "" {
+			sb.WriteString("=")
+			sb.WriteString(formatValue(value))
+		}
+	}
+
+	return sb.String()
+}
+
+var valueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+// formatValue quotes a value only when it would otherwise be parsed differently.
+func formatValue(value string) string {
+	if !strings.ContainsAny(value, " \t\n\"\\") {
+		return value
+	}
+
+	return `"` + valueEscaper.Replace(value) + `"`
+}
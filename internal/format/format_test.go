@@ -0,0 +1,140 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+const formatInput = `-- :cuttle version=1
+-- :repository   dialects=sqlite,postgres name=UsersRepository
+-- :doc
+--   UsersRepository   provides access to users.
+-- :end
+-- Lists users.
+-- :query mode=queryMany name=ListUsers
+-- :col type=int64 name=id
+-- :col name=username type=string
+select id, username
+  from users where role = 'admin' -- select
+   order by id;
+-- :query name=DeleteUser mode=exec
+-- :arg name=id type=int64
+
+
+
+delete from users where id = :id;
+`
+
+func TestFormat(t *testing.T) {
+	want := `-- :cuttle version=1
+-- :repository name=UsersRepository dialects=sqlite,postgres
+-- :doc
+--   UsersRepository   provides access to users.
+-- :end
+
+-- Lists users.
+-- :query name=ListUsers mode=queryMany
+-- :col name=id type=int64
+-- :col name=username type=string
+SELECT id, username
+  FROM users WHERE role = 'admin' -- select
+   ORDER BY id;
+
+-- :query name=DeleteUser mode=exec
+-- :arg name=id type=int64
+
+
+
+DELETE FROM users WHERE id = :id;
+`
+
+	got, err := Format([]byte(formatInput), "users.sql", Options{Keywords: KeywordsUpper})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != want {
+		t.Errorf("Format() =\n%v\nwant\n%v", string(got), want)
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	inputs := map[string]string{
+		"lf":   formatInput,
+		"crlf": strings.ReplaceAll(formatInput, "\n", "\r\n"),
+	}
+
+	for name, input := range inputs {
+		for _, keywords := range []string{KeywordsPreserve, KeywordsUpper, KeywordsLower} {
+			t.Run(name+"/"+keywords, func(t *testing.T) {
+				opts := Options{Keywords: keywords}
+
+				first, err := Format([]byte(input), "users.sql", opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				second, err := Format(first, "users.sql", opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(first) != string(second) {
+					t.Errorf("formatting is not idempotent:\n%q\n%q", first, second)
+				}
+			})
+		}
+	}
+}
+
+func TestFormatLineEndings(t *testing.T) {
+	input := strings.ReplaceAll(formatInput, "\n", "\r\n")
+
+	got, err := Format([]byte(input), "users.sql", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(string(got), "\n") != strings.Count(string(got), "\r\n") {
+		t.Errorf("Format() mixed line endings: %q", got)
+	}
+
+	// Comments and doc blocks are preserved byte for byte
+	for _, preserved := range []string{
+		"\r\n--   UsersRepository   provides access to users.\r\n",
+		"\r\n  from users where role = 'admin' -- select\r\n",
+	} {
+		if !strings.Contains(string(got), preserved) {
+			t.Errorf("Format() did not preserve %q", preserved)
+		}
+	}
+
+	formatted, err := Format(got, "users.sql", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(formatted) != string(got) {
+		t.Errorf("formatted file changed when formatted again")
+	}
+}
+
+func TestCaseKeywords(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{sql: "select id from users", want: "SELECT id FROM users"},
+		{sql: "select 'select', \"from\" -- where\nfrom t", want: "SELECT 'select', \"from\" -- where\nFROM t"},
+		{sql: "select t.order, x::text from t where a = :limit", want: "SELECT t.order, x::text FROM t WHERE a = :limit"},
+		{sql: "select $$in$$, $tag$and$tag$", want: "SELECT $$in$$, $tag$and$tag$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			if got := caseKeywords(tt.sql, true); got != tt.want {
+				t.Errorf("caseKeywords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/sqlscan"
+)
+
+// keywords contains the SQL keywords recased by the formatter. Words commonly used as column names, such as key and
+// value, are omitted as recasing them would change the names of inferred columns.
+var keywords = map[string]struct{}{
+	"all": {}, "and": {}, "as": {}, "asc": {}, "between": {}, "by": {}, "case": {}, "conflict": {}, "create": {},
+	"cross": {}, "default": {}, "delete": {}, "desc": {}, "distinct": {}, "do": {}, "else": {}, "end": {}, "except": {},
+	"exists": {}, "false": {}, "from": {}, "full": {}, "group": {}, "having": {}, "if": {}, "in": {}, "inner": {},
+	"insert": {}, "intersect": {}, "into": {}, "is": {}, "join": {}, "left": {}, "like": {}, "limit": {}, "not": {},
+	"nothing": {}, "null": {}, "offset": {}, "on": {}, "or": {}, "order": {}, "outer": {}, "over": {}, "partition": {},
+	"returning": {}, "right": {}, "select": {}, "set": {}, "table": {}, "then": {}, "true": {}, "union": {},
+	"update": {}, "using": {}, "values": {}, "when": {}, "where": {}, "with": {},
+}
+
+// caseKeywords recases the keywords of a statement, skipping quoted strings, identifiers, comments and parameters.
+func caseKeywords(sql string, upper bool) string {
+	var sb strings.Builder
+
+	for _, tk := range sqlscan.Scan(sql) {
+		word := tk.Text
+
+		// Qualified names, casts and numbers are never keywords
+		qualified := tk.Start > 0 && strings.IndexByte(":.", sql[tk.Start-1]) != -1
+
+		if tk.Kind == sqlscan.KindWord && !qualified && !isDigit(word[0]) {
+			if _, ok := keywords[strings.ToLower(word)]; ok && upper {
+				word = strings.ToUpper(word)
+			} else if ok {
+				word = strings.ToLower(word)
+			}
+		}
+
+		sb.WriteString(word)
+	}
+
+	return sb.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"github.com/csnewman/cuttle/internal/parser"
+	"github.com/dave/jennifer/jen"
+)
+
+// copyRowsParam is the parameter of copy methods containing the rows to insert.
+const copyRowsParam = "rows"
+
+// copyRowType returns the type of a row inserted by a copy query, which is the type of the arg when the query has a
+// single arg, or a struct with a field per arg otherwise.
+func (g *Generator) copyRowType(query *parser.Query) jen.Code {
+	if len(query.Args) == 1 {
+		return argType(query.Args[0])
+	}
+
+	return jen.Id(g.rowTypeName(query))
+}
+
+// generateCopyRowType emits the row struct for copy queries with multiple args.
+func (g *Generator) generateCopyRowType(query *parser.Query) {
+	if len(query.Args) == 1 || g.emitted[g.rowTypeName(query)] {
+		return
+	}
+
+	g.emitted[g.rowTypeName(query)] = true
+
+	g.file.Line()
+	g.file.Type().Id(g.rowTypeName(query)).StructFunc(copyRowFields(query))
+}
+
+func copyRowFields(query *parser.Query) func(*jen.Group) {
+	return func(jg *jen.Group) {
+		for _, arg := range query.Args {
+			jg.Id(argField(arg)).Add(argType(arg))
+		}
+	}
+}
+
+func argField(arg *parser.Arg) string {
+	return colField(&parser.Col{Name: arg.Name})
+}
+
+// generateCopy emits the method of a copy query, which inserts each row using the bulk loading support of the driver.
+// Copy queries have no Async variant, as batches can not contain copies.
+func (g *Generator) generateCopy(repo *parser.Repository, query *parser.Query, jg *jen.Group, implName string) {
+	g.generateCopyRowType(query)
+
+	docComment(jg, query.Doc)
+	jg.Id(query.Name).ParamsFunc(g.queryParams(query)).ParamsFunc(g.queryResults(query))
+
+	// The value of an arg within the current row
+	value := func(arg *parser.Arg) *jen.Statement {
+		row := jen.Id(copyRowsParam).Index(jen.Id("i"))
+
+		if len(query.Args) == 1 {
+			return row
+		}
+
+		return row.Dot(argField(arg))
+	}
+
+	g.file.Line()
+	g.file.Func().Params(jen.Id("r").Op("*").Id(implName)).Id(query.Name).
+		ParamsFunc(g.queryParams(query)).
+		ParamsFunc(g.queryResults(query)).
+		BlockFunc(func(jg *jen.Group) {
+			jg.Var().Id("cuttleResValue").Int64()
+			jg.Line()
+
+			jg.Id("cuttleErr").Op(":=").Id("tx").Dot("CopyFromFunc").CallFunc(func(jg *jen.Group) {
+				jg.Line().Id("ctx")
+				jg.Line().Func().
+					Params(
+						jen.Id("ctx").Qual("context", "Context"),
+						jen.Id("result").Qual(cuttlePkg, "Exec"),
+					).
+					Error().
+					Block(
+						jen.Id("cuttleResValue").Op("=").Id("result").Dot("RowsAffected").Call(),
+						jen.Line(),
+						jen.Return(jen.Nil()),
+					)
+				jg.Line().Lit(query.Table)
+				jg.Line().Index().String().ValuesFunc(func(jg *jen.Group) {
+					for _, arg := range query.Args {
+						jg.Lit(arg.Column)
+					}
+				})
+				jg.Line().Qual(cuttlePkg, "CopyFromSlice").Call(
+					jen.Len(jen.Id(copyRowsParam)),
+					jen.Func().Params(jen.Id("i").Int()).Params(jen.Index().Any(), jen.Error()).BlockFunc(func(jg *jen.Group) {
+						if g.hasArgConversions(repo, query) {
+							g.generateArgConversions(jg, repo, query, value)
+							jg.Line()
+						}
+
+						jg.Return(
+							jen.Index().Any().ValuesFunc(func(jg *jen.Group) {
+								for i, arg := range query.Args {
+									jg.Add(g.argBinding(repo, i, arg, value))
+								}
+							}),
+							jen.Nil(),
+						)
+					}),
+				)
+				jg.Line()
+			})
+			jg.Line()
+
+			jg.Return(jen.Id("cuttleResValue"), jen.Id("cuttleErr"))
+		})
+}
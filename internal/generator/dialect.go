@@ -1,21 +1,45 @@
 package generator
 
+import (
+	"slices"
+)
+
 var dialectConfigs = map[string]dialectConfig{
 	"generic": {
-		VarName: "DialectGeneric",
-		IDEName: "sql",
+		VarName:     "DialectGeneric",
+		IDEName:     "sql",
+		Placeholder: "?%v",
 	},
 	"sqlite": {
-		VarName: "DialectSQLite",
-		IDEName: "sqlite",
+		VarName:     "DialectSQLite",
+		IDEName:     "sqlite",
+		Placeholder: "?%v",
 	},
 	"postgres": {
-		VarName: "DialectPostgres",
-		IDEName: "postgresql",
+		VarName:     "DialectPostgres",
+		IDEName:     "postgresql",
+		Placeholder: "$%v",
+		ArrayArgs:   true,
 	},
 }
 
 type dialectConfig struct {
-	VarName string
-	IDEName string
+	VarName     string
+	IDEName     string
+	Placeholder string
+	// ArrayArgs matches cuttle.Dialect.ArrayArgs, with list args being bound as a single array parameter.
+	ArrayArgs bool
+}
+
+// Dialects returns the names of the supported dialects.
+func Dialects() []string {
+	names := make([]string, 0, len(dialectConfigs))
+
+	for name := range dialectConfigs {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
 }
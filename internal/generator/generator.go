@@ -3,6 +3,10 @@ package generator
 import (
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/csnewman/cuttle/internal/parser"
 	"github.com/dave/jennifer/jen"
@@ -11,40 +15,106 @@ import (
 
 const cuttlePkg = "github.com/csnewman/cuttle"
 
-func Generate(unit *parser.Unit, logger *slog.Logger, outPath string) error {
-	f := jen.NewFile("main")
-	f.HeaderComment("Code generated by " + cuttlePkg + ". DO NOT EDIT")
+type Options struct {
+	// Package is the name of the generated package. It defaults to main, or to the last element of ImportPath when
+	// using LayoutPackages.
+	Package string
+	// Layout selects how repositories are split between files, either LayoutFile, LayoutFiles or LayoutPackages.
+	Layout string
+	// ImportPath is the import path of the output directory, required by LayoutPackages.
+	ImportPath string
+	// Mappings contains the type mappings of each dialect.
+	Mappings map[string][]*TypeMapping
+}
+
+// Generate writes the generated code for the unit. The output path is a file when using LayoutFile, or a directory
+// otherwise.
+func Generate(unit *parser.Unit, logger *slog.Logger, outPath string, opts Options) error {
+	files, err := Render(unit, logger, outPath, opts)
+	if err != nil {
+		return err
+	}
+
+	orphans, err := Orphans(outPath, opts, files)
+	if err != nil {
+		return err
+	}
+
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
 
-	g := &Generator{
-		logger: logger,
-		file:   f,
+		if err := os.WriteFile(path, data, 0o644); err != nil { //nolint:gosec
+			return err
+		}
 	}
 
-	g.Generate(unit)
+	for _, path := range orphans {
+		logger.Info("Removing orphaned generated file", "path", path)
 
-	return f.Save(outPath)
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		// The package directory of a removed repository is left behind when empty, so attempt to remove it too
+		if dir := filepath.Dir(path); dir != filepath.Clean(outPath) {
+			_ = os.Remove(dir)
+		}
+	}
+
+	return nil
 }
 
 type Generator struct {
-	logger *slog.Logger
-	file   *jen.File
+	logger   *slog.Logger
+	file     *jen.File
+	mappings map[string][]*TypeMapping
+	// rowsPath is the import path of the package containing the row types shared between repositories
+	rowsPath string
+	// shared contains the keys of the row types shared between repositories, which are emitted separately
+	shared map[string]bool
+	// emitted contains the names of the row types emitted to the file
+	emitted map[string]bool
+	// prefixRows prefixes row types with the name of their repository, as repositories sharing a package may contain
+	// queries with the same name
+	prefixRows bool
+	// rowPrefix is the prefix of the row types of the repository being generated
+	rowPrefix string
 }
 
-func (g *Generator) Generate(unit *parser.Unit) {
-	g.file.ImportName(cuttlePkg, "cuttle")
-
-	for _, name := range unit.RepositoriesOrder {
-		repo := unit.Repositories[name]
-
-		g.GenerateRepo(repo)
+func newGenerator(
+	logger *slog.Logger,
+	file *jen.File,
+	opts Options,
+	rowsPath string,
+	shared map[string]bool,
+	prefixRows bool,
+) *Generator {
+	file.HeaderComment("Code generated by " + cuttlePkg + ". DO NOT EDIT")
+	file.ImportName(cuttlePkg, "cuttle")
+
+	return &Generator{
+		logger:     logger,
+		file:       file,
+		mappings:   opts.Mappings,
+		rowsPath:   rowsPath,
+		shared:     shared,
+		emitted:    make(map[string]bool),
+		prefixRows: prefixRows,
 	}
 }
 
 func (g *Generator) GenerateRepo(repo *parser.Repository) {
 	g.logger.Debug("Generating repository", "name", repo.Name)
 
+	if g.prefixRows {
+		g.rowPrefix = rowPrefix(repo)
+	}
+
+	docComment(g.file.Group, repo.Doc)
 	g.file.Type().Id(repo.Name).InterfaceFunc(func(jg *jen.Group) {
-		implName := strcase.ToLowerCamel(repo.Name) + "Impl"
+		implName := implName(repo)
 		dialectsVar := implName + "Dialects"
 
 		g.file.Line()
@@ -96,81 +166,53 @@ func (g *Generator) GenerateRepo(repo *parser.Repository) {
 			jg.Line()
 		}
 	})
+
+	g.generateStore(repo)
 }
 
 func (g *Generator) generateQuery(repo *parser.Repository, query *parser.Query, jg *jen.Group, implName string) {
 	g.logger.Debug("Generating query", "name", query.Name)
 
+	if query.Mode == parser.ModeCopy {
+		g.generateCopy(repo, query, jg, implName)
+
+		return
+	}
+
 	var (
-		resultPath  string
-		resultType  string
-		txType      string
 		queryFunc   string
 		queryResult string
 	)
 
-	txType = "WTx"
-
 	switch query.Mode {
 	case parser.ModeExec:
 		queryFunc = "Exec"
 		queryResult = "Exec"
-		resultPath = ""
-		resultType = "int64"
 
-	case parser.ModeQueryMany:
+	case parser.ModeQueryMany, parser.ModeExecMany:
 		queryFunc = "Query"
 		queryResult = "Rows"
 
-		panic("todo")
+		g.generateRowType(query)
 
-	case parser.ModeQueryRow:
+	case parser.ModeQueryRow, parser.ModeExecRow:
 		queryFunc = "QueryRow"
 		queryResult = "Row"
 
-		if len(query.Cols) == 1 {
-			resultPath = ""
-			resultType = query.Cols[0].Type
-		} else {
-			panic("todo")
-		}
-
-		resultPath = ""
-		resultType = "int64"
+		g.generateRowType(query)
 
 	default:
 		panic("unexpected " + query.Mode)
 	}
 
-	jg.Id(query.Name).ParamsFunc(func(jg *jen.Group) {
-		jg.Line().Id("ctx").Qual("context", "Context")
-		jg.Line().Id("tx").Qual(cuttlePkg, txType+"Funcer")
-
-		for _, arg := range query.Args {
-			jg.Line().Id(arg.Name).Qual("", arg.Type)
-		}
+	resultType := g.queryResultType(query)
 
-		jg.Line()
-	}).ParamsFunc(func(jg *jen.Group) {
-		jg.Qual(resultPath, resultType)
-		jg.Id("error")
-	})
+	docComment(jg, query.Doc)
+	jg.Id(query.Name).ParamsFunc(g.queryParams(query)).ParamsFunc(g.queryResults(query))
 
 	jg.Line()
 
-	jg.Id(query.Name + "Async").ParamsFunc(func(jg *jen.Group) {
-		jg.Line().Id("tx").Qual(cuttlePkg, "Async"+txType)
-
-		for _, arg := range query.Args {
-			jg.Line().Id(arg.Name).Qual("", arg.Type)
-		}
-
-		jg.Line().Id("callback").Qual(cuttlePkg, "AsyncHandler").Types(
-			jen.Qual(resultPath, resultType),
-		)
-
-		jg.Line()
-	})
+	jg.Id(query.Name + "Async").ParamsFunc(g.asyncParams(query))
 
 	generateStmtSelector := func(jg *jen.Group) {
 		jg.Var().Id("cuttleStmt").Id("string")
@@ -182,7 +224,7 @@ func (g *Generator) generateQuery(repo *parser.Repository, query *parser.Query,
 			cfg := dialectConfigs[dialect]
 
 			cases = append(cases, jen.Case(jen.Lit(i)).BlockFunc(func(jg *jen.Group) {
-				stmt := fmt.Sprintf("/* %v:%v */ %v", repo.Name, query.Name, variant.Stmt)
+				stmt := fmt.Sprintf("/* %v:%v */ %v", repo.Name, query.Name, variantStmt(query, variant, cfg))
 
 				jg.Comment("language=" + cfg.IDEName)
 				jg.Id("cuttleStmt").Op("=").Custom(jen.Options{
@@ -199,27 +241,30 @@ func (g *Generator) generateQuery(repo *parser.Repository, query *parser.Query,
 		jg.Switch(jen.Id("r").Dot("dialectIndex")).Block(cases...)
 	}
 
-	g.file.Line()
-	g.file.Func().Params(jen.Id("r").Op("*").Id(implName)).Id(query.Name).
-		ParamsFunc(func(jg *jen.Group) {
-			jg.Line().Id("ctx").Qual("context", "Context")
-			jg.Line().Id("tx").Qual(cuttlePkg, txType+"Funcer")
+	generateArgs := func(jg *jen.Group) {
+		jg.Line().Id("cuttleStmt")
 
-			for _, arg := range query.Args {
-				jg.Line().Id(arg.Name).Qual("", arg.Type)
-			}
+		for i, arg := range query.Args {
+			jg.Line().Add(g.argBinding(repo, i, arg, argIdent))
+		}
 
-			jg.Line()
-		}).
-		ParamsFunc(func(jg *jen.Group) {
-			jg.Qual(resultPath, resultType)
-			jg.Id("error")
-		}).
+		jg.Line()
+	}
+
+	g.file.Line()
+	g.file.Func().Params(jen.Id("r").Op("*").Id(implName)).Id(query.Name).
+		ParamsFunc(g.queryParams(query)).
+		ParamsFunc(g.queryResults(query)).
 		BlockFunc(func(jg *jen.Group) {
 			generateStmtSelector(jg)
 			jg.Line()
 
-			jg.Var().Id("cuttleResValue").Qual(resultPath, resultType)
+			if g.hasArgConversions(repo, query) {
+				g.generateArgConversions(jg, repo, query, argIdent)
+				jg.Line()
+			}
+
+			jg.Var().Id("cuttleResValue").Add(resultType)
 			jg.Line()
 
 			jg.Id("cuttleErr").Op(":=").Id("tx").Dot(queryFunc + "Func").
@@ -238,25 +283,63 @@ func (g *Generator) generateQuery(repo *parser.Repository, query *parser.Query,
 								jg.Line()
 								jg.Return(jen.Id("nil"))
 
-							case parser.ModeQueryRow:
-								jg.Return(jen.Id("result").
-									Dot("Scan").
-									ParamsFunc(func(g *jen.Group) {
-										g.Op("&").Id("cuttleResValue")
-									}))
+							case parser.ModeQueryRow, parser.ModeExecRow:
+								targets, decode := g.scanTargets(repo, query, "cuttleResValue", false)
+
+								if len(decode) == 0 {
+									jg.Return(jen.Id("result").Dot("Scan").Params(targets...))
+
+									break
+								}
+
+								g.declareScanTemps(jg, repo, query, "cuttleResValue")
+								jg.Line()
+
+								jg.If(
+									jen.Err().Op(":=").Id("result").Dot("Scan").Params(targets...),
+									jen.Err().Op("!=").Nil(),
+								).Block(jen.Return(jen.Err()))
+								jg.Line()
+
+								for _, c := range decode {
+									jg.Add(c)
+								}
+
+								jg.Line()
+								jg.Return(jen.Id("nil"))
+
+							case parser.ModeQueryMany, parser.ModeExecMany:
+								targets, decode := g.scanTargets(repo, query, "cuttleRow", false)
+
+								jg.For().BlockFunc(func(jg *jen.Group) {
+									jg.Var().Id("cuttleRow").Add(g.rowType(query))
+									g.declareScanTemps(jg, repo, query, "cuttleRow")
+									jg.Line()
+
+									jg.List(jen.Id("ok"), jen.Err()).Op(":=").Id("result").Dot("Next").Params(targets...)
+									jg.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Err()))
+									jg.Line()
+
+									jg.If(jen.Op("!").Id("ok")).Block(jen.Return(jen.Nil()))
+									jg.Line()
+
+									if len(decode) > 0 {
+										for _, c := range decode {
+											jg.Add(c)
+										}
+
+										jg.Line()
+									}
+
+									jg.Id("cuttleResValue").Op("=").Append(jen.Id("cuttleResValue"), jen.Id("cuttleRow"))
+								})
 
 							default:
 								panic("unexpected " + query.Mode)
 							}
 						})
 
-					jg.Line().Id("cuttleStmt")
-
-					for _, arg := range query.Args {
-						jg.Line().Id(arg.Name)
-					}
-
-					jg.Line()
+					generateArgs(jg)
 				})
 
 			jg.Line()
@@ -265,24 +348,17 @@ func (g *Generator) generateQuery(repo *parser.Repository, query *parser.Query,
 
 	g.file.Line()
 	g.file.Func().Params(jen.Id("r").Op("*").Id(implName)).Id(query.Name + "Async").
-		ParamsFunc(func(jg *jen.Group) {
-			jg.Line().Id("tx").Qual(cuttlePkg, "Async"+txType)
-
-			for _, arg := range query.Args {
-				jg.Line().Id(arg.Name).Qual("", arg.Type)
-			}
-
-			jg.Line().Id("callback").Qual(cuttlePkg, "AsyncHandler").Types(
-				jen.Qual(resultPath, resultType),
-			)
-
-			jg.Line()
-		}).
+		ParamsFunc(g.asyncParams(query)).
 		BlockFunc(func(jg *jen.Group) {
 			generateStmtSelector(jg)
 
 			jg.Line()
 
+			if g.hasArgConversions(repo, query) {
+				g.generateArgConversions(jg, repo, query, argIdent)
+				jg.Line()
+			}
+
 			jg.Id("tx").Dot(queryFunc).
 				ParamsFunc(func(jg *jen.Group) {
 					jg.Line().Func().
@@ -303,19 +379,53 @@ func (g *Generator) generateQuery(repo *parser.Repository, query *parser.Query,
 								)
 								jg.Line()
 
-							case parser.ModeQueryRow:
-								jg.Var().Id("cuttleResValue").Qual(resultPath, resultType)
+							case parser.ModeQueryRow, parser.ModeExecRow:
+								targets, decode := g.scanTargets(repo, query, "cuttleResValue", true)
+
+								jg.Var().Id("cuttleResValue").Add(resultType)
+								g.declareScanTemps(jg, repo, query, "cuttleResValue")
 								jg.Line()
 
 								jg.If(jen.Id("err").Op("==").Id("nil")).Block(
 									jen.Id("err").Op("=").Id("result").
 										Dot("Scan").
-										ParamsFunc(func(g *jen.Group) {
-											g.Op("&").Id("cuttleResValue")
-										}),
+										Params(targets...),
 								)
 								jg.Line()
 
+								if len(decode) > 0 {
+									jg.If(jen.Id("err").Op("==").Id("nil")).Block(decode...)
+									jg.Line()
+								}
+
+							case parser.ModeQueryMany, parser.ModeExecMany:
+								targets, decode := g.scanTargets(repo, query, "cuttleRow", true)
+
+								jg.Var().Id("cuttleResValue").Add(resultType)
+								jg.Line()
+
+								jg.For(jen.Id("err").Op("==").Id("nil")).BlockFunc(func(jg *jen.Group) {
+									jg.Var().Id("cuttleRow").Add(g.rowType(query))
+									g.declareScanTemps(jg, repo, query, "cuttleRow")
+									jg.Var().Id("ok").Bool()
+									jg.Line()
+
+									jg.List(jen.Id("ok"), jen.Err()).Op("=").Id("result").Dot("Next").Params(targets...)
+									jg.If(jen.Err().Op("!=").Nil().Op("||").Op("!").Id("ok")).Block(jen.Break())
+									jg.Line()
+
+									if len(decode) > 0 {
+										for _, c := range decode {
+											jg.Add(c)
+										}
+
+										jg.Line()
+									}
+
+									jg.Id("cuttleResValue").Op("=").Append(jen.Id("cuttleResValue"), jen.Id("cuttleRow"))
+								})
+								jg.Line()
+
 							default:
 								panic("unexpected " + query.Mode)
 							}
@@ -327,13 +437,357 @@ func (g *Generator) generateQuery(repo *parser.Repository, query *parser.Query,
 							}))
 						})
 
-					jg.Line().Id("cuttleStmt")
-
-					for _, arg := range query.Args {
-						jg.Line().Id(arg.Name)
-					}
-
-					jg.Line()
+					generateArgs(jg)
 				})
 		})
 }
+
+// docComment emits the lines of a doc block as Go comments.
+func docComment(jg *jen.Group, doc *parser.Doc) {
+	if doc == nil {
+		return
+	}
+
+	for _, line := range doc.Text() {
+		jg.Comment(line)
+	}
+}
+
+// queryTxType returns the transaction type required by a query.
+func queryTxType(query *parser.Query) string {
+	switch {
+	case query.Write, query.Mode == parser.ModeExec, query.Mode == parser.ModeExecMany, query.Mode == parser.ModeExecRow,
+		query.Mode == parser.ModeCopy:
+		return "WTx"
+	default:
+		return "RTx"
+	}
+}
+
+func (g *Generator) queryResultType(query *parser.Query) jen.Code {
+	switch query.Mode {
+	case parser.ModeExec, parser.ModeCopy:
+		return jen.Int64()
+	case parser.ModeQueryMany, parser.ModeExecMany:
+		return jen.Index().Add(g.rowType(query))
+	case parser.ModeQueryRow, parser.ModeExecRow:
+		return g.rowType(query)
+	default:
+		panic("unexpected " + query.Mode)
+	}
+}
+
+// inputParams returns the parameters holding the input of a query, which are its args, or the rows to insert for copy
+// queries.
+func (g *Generator) inputParams(query *parser.Query) func(*jen.Group) {
+	return func(jg *jen.Group) {
+		if query.Mode == parser.ModeCopy {
+			jg.Line().Id(copyRowsParam).Index().Add(g.copyRowType(query))
+
+			return
+		}
+
+		for _, arg := range query.Args {
+			jg.Line().Id(arg.Name).Add(argType(arg))
+		}
+	}
+}
+
+// inputNames returns the names of the parameters returned by inputParams.
+func inputNames(query *parser.Query) []jen.Code {
+	if query.Mode == parser.ModeCopy {
+		return []jen.Code{jen.Id(copyRowsParam)}
+	}
+
+	names := make([]jen.Code, 0, len(query.Args))
+
+	for _, arg := range query.Args {
+		names = append(names, jen.Id(arg.Name))
+	}
+
+	return names
+}
+
+func (g *Generator) queryParams(query *parser.Query) func(*jen.Group) {
+	return func(jg *jen.Group) {
+		jg.Line().Id("ctx").Qual("context", "Context")
+		jg.Line().Id("tx").Qual(cuttlePkg, queryTxType(query)+"Funcer")
+		g.inputParams(query)(jg)
+		jg.Line()
+	}
+}
+
+func (g *Generator) queryResults(query *parser.Query) func(*jen.Group) {
+	return func(jg *jen.Group) {
+		jg.Add(g.queryResultType(query))
+		jg.Id("error")
+	}
+}
+
+func (g *Generator) asyncParams(query *parser.Query) func(*jen.Group) {
+	return func(jg *jen.Group) {
+		jg.Line().Id("tx").Qual(cuttlePkg, "Async"+queryTxType(query))
+
+		for _, arg := range query.Args {
+			jg.Line().Id(arg.Name).Add(argType(arg))
+		}
+
+		jg.Line().Id("callback").Qual(cuttlePkg, "AsyncHandler").Types(
+			g.queryResultType(query),
+		)
+
+		jg.Line()
+	}
+}
+
+// Signature returns the Go declarations generated for a query using the given layout, for use by editor tooling. The
+// methods are shown with the repository interface as their receiver.
+func Signature(repo *parser.Repository, query *parser.Query, layout string) string {
+	g := &Generator{rowPrefix: layoutRowPrefix(repo, layout)}
+
+	code := jen.Func().Params(jen.Id(repo.Name)).Id(query.Name).
+		ParamsFunc(g.queryParams(query)).
+		ParamsFunc(g.queryResults(query)).
+		Line().
+		Line().
+		Func().Params(jen.Id(repo.Name)).Id(query.Name + "Async").
+		ParamsFunc(g.asyncParams(query))
+
+	if query.Mode == parser.ModeCopy {
+		code = jen.Func().Params(jen.Id(repo.Name)).Id(query.Name).
+			ParamsFunc(g.queryParams(query)).
+			ParamsFunc(g.queryResults(query))
+
+		if len(query.Args) > 1 {
+			code.Line().Line().Type().Id(g.rowTypeName(query)).StructFunc(copyRowFields(query))
+		}
+	}
+
+	if query.Mode != parser.ModeExec && len(query.Cols) > 1 {
+		code.Line().Line().Type().Id(g.rowTypeName(query)).StructFunc(rowFields(query))
+	}
+
+	return fmt.Sprintf("%#v", code)
+}
+
+// variantStmt returns the statement of a variant, replacing named parameters with the positional syntax of the dialect.
+// List args used as "IN (:arg)" are bound as a single array by dialects with array support.
+func variantStmt(query *parser.Query, variant *parser.Variant, cfg dialectConfig) string {
+	if variant.Parts == nil {
+		return variant.Stmt
+	}
+
+	var sb strings.Builder
+
+	for _, part := range variant.Parts {
+		if part.Arg == nil {
+			sb.WriteString(part.Text)
+
+			continue
+		}
+
+		placeholder := fmt.Sprintf(cfg.Placeholder, slices.Index(query.Args, part.Arg)+1)
+
+		switch {
+		case !part.In:
+			sb.WriteString(placeholder)
+		case cfg.ArrayArgs && part.Not:
+			sb.WriteString("<> ALL(" + placeholder + ")")
+		case cfg.ArrayArgs:
+			sb.WriteString("= ANY(" + placeholder + ")")
+		case part.Not:
+			sb.WriteString("NOT IN (" + placeholder + ")")
+		default:
+			sb.WriteString("IN (" + placeholder + ")")
+		}
+	}
+
+	return sb.String()
+}
+
+// generateRowType emits the row struct for queries returning multiple columns, unless the struct is shared with other
+// repositories or has already been emitted.
+func (g *Generator) generateRowType(query *parser.Query) {
+	name := g.rowTypeName(query)
+
+	if len(query.Cols) == 1 || g.shared[rowKey(name, query)] || g.emitted[name] {
+		return
+	}
+
+	g.emitted[name] = true
+
+	g.file.Line()
+	g.file.Type().Id(name).StructFunc(rowFields(query))
+}
+
+func rowFields(query *parser.Query) func(*jen.Group) {
+	return func(jg *jen.Group) {
+		for _, col := range query.Cols {
+			jg.Id(colField(col)).Add(colType(col))
+		}
+	}
+}
+
+// rowType returns the type of a single result row.
+func (g *Generator) rowType(query *parser.Query) jen.Code {
+	if len(query.Cols) == 1 {
+		return colType(query.Cols[0])
+	}
+
+	name := g.rowTypeName(query)
+
+	if g.shared[rowKey(name, query)] {
+		return jen.Qual(g.rowsPath, name)
+	}
+
+	return jen.Id(name)
+}
+
+func (g *Generator) rowTypeName(query *parser.Query) string {
+	return rowTypeName(g.rowPrefix, query)
+}
+
+// rowTypeName returns the name of the row type of a query, such as UsersGetRow for the query Get of UsersRepository.
+func rowTypeName(prefix string, query *parser.Query) string {
+	return prefix + query.Name + "Row"
+}
+
+// implName returns the name of the unexported struct implementing a repository.
+func implName(repo *parser.Repository) string {
+	return strcase.ToLowerCamel(repo.Name) + "Impl"
+}
+
+// TypeNames returns the names of the types generated for a repository, being the interface, its implementation and its
+// store, for use by editor tooling.
+func TypeNames(repo *parser.Repository) []string {
+	return []string{repo.Name, implName(repo), storeName(repo)}
+}
+
+// RowTypeName returns the name of the row type of a query generated using the given layout, for use by editor tooling.
+func RowTypeName(repo *parser.Repository, query *parser.Query, layout string) string {
+	return rowTypeName(layoutRowPrefix(repo, layout), query)
+}
+
+// rowPrefix returns the prefix of the row types of a repository.
+func rowPrefix(repo *parser.Repository) string {
+	return strings.TrimSuffix(repo.Name, "Repository")
+}
+
+// layoutRowPrefix returns the prefix of the row types of a repository, which are only prefixed when repositories share
+// a package.
+func layoutRowPrefix(repo *parser.Repository, layout string) string {
+	if layout == LayoutPackages {
+		return ""
+	}
+
+	return rowPrefix(repo)
+}
+
+func colField(col *parser.Col) string {
+	return strcase.ToCamel(col.Name)
+}
+
+// scanTargets returns the scan destinations for the columns of a query, along with the statements required to decode
+// any values that are scanned into temporaries.
+func (g *Generator) scanTargets(
+	repo *parser.Repository,
+	query *parser.Query,
+	dest string,
+	async bool,
+) ([]jen.Code, []jen.Code) {
+	var (
+		targets []jen.Code
+		decode  []jen.Code
+	)
+
+	field := colTarget(query, dest)
+
+	for i, col := range query.Cols {
+		if col.JSON {
+			temp := scanTempName(i)
+
+			targets = append(targets, jen.Op("&").Id(temp))
+			decode = append(decode, field(i).Op("=").Id(temp).Dot("V"))
+
+			continue
+		}
+
+		if len(g.typeMappings(repo, col.GoType, false, col.JSON)) > 0 {
+			targets = append(targets, jen.Id(scanDestName(i)))
+
+			continue
+		}
+
+		targets = append(targets, jen.Op("&").Add(field(i)))
+	}
+
+	decode = append(decode, g.decodeMapped(repo, query, field, async)...)
+
+	return targets, decode
+}
+
+// colTarget returns a function building the field that the column at the given index is scanned into.
+func colTarget(query *parser.Query, dest string) func(int) *jen.Statement {
+	return func(i int) *jen.Statement {
+		if len(query.Cols) > 1 {
+			return jen.Id(dest).Dot(colField(query.Cols[i]))
+		}
+
+		return jen.Id(dest)
+	}
+}
+
+func (g *Generator) declareScanTemps(jg *jen.Group, repo *parser.Repository, query *parser.Query, dest string) {
+	for i, col := range query.Cols {
+		if col.JSON {
+			jg.Var().Id(scanTempName(i)).Qual(cuttlePkg, "JSON").Types(goType(col.GoType))
+		}
+	}
+
+	g.declareMappedTemps(jg, repo, query, colTarget(query, dest))
+}
+
+func scanTempName(i int) string {
+	return fmt.Sprintf("cuttleCol%v", i)
+}
+
+func argType(arg *parser.Arg) jen.Code {
+	if arg.List {
+		return jen.Index().Add(nullableType(arg.GoType, arg.Nullable))
+	}
+
+	return nullableType(arg.GoType, arg.Nullable)
+}
+
+func colType(col *parser.Col) jen.Code {
+	return nullableType(col.GoType, col.Nullable)
+}
+
+func argValue(arg *parser.Arg) jen.Code {
+	return wrapArg(arg, argIdent(arg))
+}
+
+func argIdent(arg *parser.Arg) *jen.Statement {
+	return jen.Id(arg.Name)
+}
+
+// wrapArg returns the value bound for an arg holding the given value.
+func wrapArg(arg *parser.Arg, value *jen.Statement) jen.Code {
+	if arg.JSON {
+		return jen.Qual(cuttlePkg, "NewJSON").Call(value)
+	}
+
+	if arg.List {
+		return jen.Qual(cuttlePkg, "NewList").Call(value)
+	}
+
+	return value
+}
+
+func nullableType(ty *parser.GoType, nullable bool) jen.Code {
+	if nullable {
+		return jen.Qual(cuttlePkg, "Null").Types(goType(ty))
+	}
+
+	return goType(ty)
+}
@@ -0,0 +1,232 @@
+package generator
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// testModule is the module the generated code is compiled within, containing the types referenced by the inputs.
+const testModule = "example.com/app"
+
+const testModel = `package model
+
+type Meta struct {
+	Tags []string
+}
+
+type ID [16]byte
+
+func EncodeID(id ID) string {
+	return string(id[:])
+}
+
+func DecodeID(s string) (ID, error) {
+	var id ID
+
+	copy(id[:], s)
+
+	return id, nil
+}
+`
+
+func mustParseGoType(t *testing.T, raw string) *parser.GoType {
+	t.Helper()
+
+	ty, err := parser.ParseGoType(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ty
+}
+
+func TestRender(t *testing.T) {
+	idMapping := &TypeMapping{
+		Type:   mustParseGoType(t, testModule+"/model.ID"),
+		DBType: mustParseGoType(t, "string"),
+		Encode: mustParseGoType(t, testModule+"/model.EncodeID"),
+		Decode: mustParseGoType(t, testModule+"/model.DecodeID"),
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		out   string
+		opts  Options
+	}{
+		{
+			name:  "queries",
+			input: "queries.sql",
+			out:   "queries/queries.gen.go",
+			opts:  Options{Package: "db"},
+		},
+		{
+			name:  "file",
+			input: "repos.sql",
+			out:   "file/repos.gen.go",
+			opts:  Options{Package: "db", Layout: LayoutFile},
+		},
+		{
+			name:  "files",
+			input: "repos.sql",
+			out:   "files",
+			opts:  Options{Package: "db", Layout: LayoutFiles},
+		},
+		{
+			name:  "packages",
+			input: "repos.sql",
+			out:   "packages",
+			opts:  Options{Layout: LayoutPackages, ImportPath: testModule + "/packages"},
+		},
+		{
+			name:  "mappings",
+			input: "mappings.sql",
+			out:   "mappings/mappings.gen.go",
+			opts:  Options{Package: "db", Mappings: map[string][]*TypeMapping{"sqlite": {idMapping}}},
+		},
+	}
+
+	// The generated files of every test, which are compiled together
+	generated := make(map[string][]byte)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.input)
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			unit, err := parser.Parse(f, path, logger)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := Render(unit, logger, tt.out, tt.opts)
+			if err != nil {
+				t.Fatalf("Render() failed: %v", err)
+			}
+
+			maps.Copy(generated, files)
+
+			got := archive(files)
+			goldenPath := filepath.Join("testdata", tt.name+".golden")
+
+			if *update {
+				if err := os.WriteFile(goldenPath, got, 0o644); err != nil { //nolint:gosec
+					t.Fatal(err)
+				}
+
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("Render() differs from %v, run go test with -update to regenerate:\n%s", goldenPath, got)
+			}
+		})
+	}
+
+	t.Run("compile", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skipping compilation in short mode")
+		}
+
+		compile(t, generated)
+	})
+}
+
+// archive concatenates the rendered files in order of their path, each preceded by a line containing its path.
+func archive(files map[string][]byte) []byte {
+	paths := make([]string, 0, len(files))
+
+	for path := range files {
+		paths = append(paths, path)
+	}
+
+	slices.Sort(paths)
+
+	var buf bytes.Buffer
+
+	for _, path := range paths {
+		fmt.Fprintf(&buf, "-- %v --\n", filepath.ToSlash(path))
+		buf.Write(files[path])
+	}
+
+	return buf.Bytes()
+}
+
+// compile builds and vets the generated files within a module depending on the cuttle module of this repository.
+func compile(t *testing.T, files map[string][]byte) {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goMod := fmt.Sprintf("module %v\n\ngo 1.22\n\nrequire github.com/csnewman/cuttle v0.0.0\n\n"+
+		"replace github.com/csnewman/cuttle => %v\n", testModule, root)
+
+	dir := t.TempDir()
+
+	files = maps.Clone(files)
+	files["go.mod"] = []byte(goMod)
+	files["go.sum"] = sum
+	files["model/model.go"] = []byte(testModel)
+
+	for path, data := range files {
+		path = filepath.Join(dir, path)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{{"build", "./..."}, {"vet", "./..."}} {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = dir
+		// Dependencies must already be present in the module cache
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %v failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+}
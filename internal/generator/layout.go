@@ -0,0 +1,279 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/parser"
+	"github.com/dave/jennifer/jen"
+	"github.com/iancoleman/strcase"
+)
+
+var (
+	ErrInvalidLayout   = errors.New("invalid layout")
+	ErrConflictingRows = errors.New("conflicting row types")
+)
+
+const (
+	// LayoutFile generates every repository into a single file.
+	LayoutFile = "file"
+	// LayoutFiles generates a file per repository within the output directory, all sharing a package.
+	LayoutFiles = "files"
+	// LayoutPackages generates a package per repository beneath the output directory.
+	LayoutPackages = "packages"
+)
+
+// rowsFile contains the row types shared between repositories, when using LayoutPackages.
+const rowsFile = "rows.gen.go"
+
+// Render returns the generated code for the unit, keyed by the path of each file. The output path is a file when using
+// LayoutFile, or a directory otherwise.
+func Render(unit *parser.Unit, logger *slog.Logger, outPath string, opts Options) (map[string][]byte, error) {
+	layout := opts.Layout
+	if layout == "" {
+		layout = LayoutFile
+	}
+
+	if layout != LayoutFile && layout != LayoutFiles && layout != LayoutPackages {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLayout, layout)
+	}
+
+	// Row types are prefixed with their repository when repositories share a package, which must not lead to collisions
+	if layout != LayoutPackages {
+		if err := checkRows(unit); err != nil {
+			return nil, err
+		}
+	}
+
+	pkg := opts.Package
+
+	if layout == LayoutPackages {
+		if opts.ImportPath == "" {
+			return nil, fmt.Errorf("%w: %v requires an import path", ErrInvalidLayout, layout)
+		}
+
+		if pkg == "" {
+			pkg = path.Base(opts.ImportPath)
+		}
+	} else if pkg == "" {
+		pkg = "main"
+	}
+
+	files := make(map[string]*jen.File)
+
+	if layout == LayoutFile {
+		g := newGenerator(logger, jen.NewFile(pkg), opts, "", nil, true)
+
+		for _, name := range unit.RepositoriesOrder {
+			g.GenerateRepo(unit.Repositories[name])
+		}
+
+		files[outPath] = g.file
+	} else {
+		// Identical row types of repositories in separate packages are shared through the parent package
+		var shared []*parser.Query
+
+		if layout == LayoutPackages {
+			shared = sharedRows(unit)
+		}
+
+		sharedKeys := rowKeys(shared)
+
+		for _, name := range unit.RepositoriesOrder {
+			repo := unit.Repositories[name]
+			fileName := strcase.ToSnake(repo.Name) + ".gen.go"
+
+			if layout == LayoutFiles {
+				g := newGenerator(logger, jen.NewFile(pkg), opts, "", nil, true)
+				g.GenerateRepo(repo)
+
+				files[filepath.Join(outPath, fileName)] = g.file
+
+				continue
+			}
+
+			repoPkg := strings.ToLower(repo.Name)
+
+			g := newGenerator(logger, jen.NewFile(repoPkg), opts, opts.ImportPath, sharedKeys, false)
+			g.GenerateRepo(repo)
+
+			files[filepath.Join(outPath, repoPkg, fileName)] = g.file
+		}
+
+		if len(shared) > 0 {
+			g := newGenerator(logger, jen.NewFile(pkg), opts, "", nil, false)
+
+			for _, query := range shared {
+				g.generateRowType(query)
+			}
+
+			files[filepath.Join(outPath, rowsFile)] = g.file
+		}
+	}
+
+	rendered := make(map[string][]byte, len(files))
+
+	for file, f := range files {
+		var buf bytes.Buffer
+
+		if err := f.Render(&buf); err != nil {
+			return nil, fmt.Errorf("failed to render %v: %w", file, err)
+		}
+
+		rendered[file] = buf.Bytes()
+	}
+
+	return rendered, nil
+}
+
+// rowKey identifies the definition of a row type, allowing identical row types returned by queries of different
+// repositories to be shared.
+func rowKey(name string, query *parser.Query) string {
+	var sb strings.Builder
+
+	sb.WriteString(name)
+
+	for _, col := range query.Cols {
+		fmt.Fprintf(&sb, ";%v %v %v", colField(col), col.GoType, col.Nullable)
+	}
+
+	return sb.String()
+}
+
+// hasRowType reports whether a query returns a row struct.
+func hasRowType(query *parser.Query) bool {
+	return query.Mode != parser.ModeExec && len(query.Cols) > 1
+}
+
+// sharedRows returns a query for each row type returned by more than one repository.
+func sharedRows(unit *parser.Unit) []*parser.Query {
+	var (
+		first = make(map[string]*parser.Query)
+		repos = make(map[string]map[string]bool)
+		order []string
+	)
+
+	for _, name := range unit.RepositoriesOrder {
+		for _, query := range unit.Repositories[name].Queries {
+			if !hasRowType(query) {
+				continue
+			}
+
+			key := rowKey(rowTypeName("", query), query)
+
+			if _, ok := first[key]; !ok {
+				first[key] = query
+				repos[key] = make(map[string]bool)
+				order = append(order, key)
+			}
+
+			repos[key][name] = true
+		}
+	}
+
+	var shared []*parser.Query
+
+	for _, key := range order {
+		if len(repos[key]) > 1 {
+			shared = append(shared, first[key])
+		}
+	}
+
+	return shared
+}
+
+// checkRows ensures that the prefixed row types of repositories sharing a package do not collide, such as the query
+// GetUser of Repository and the query Get of UserRepository.
+func checkRows(unit *parser.Unit) error {
+	seen := make(map[string]string)
+
+	for _, name := range unit.RepositoriesOrder {
+		repo := unit.Repositories[name]
+
+		for _, query := range repo.Queries {
+			if !hasRowType(query) && (query.Mode != parser.ModeCopy || len(query.Args) == 1) {
+				continue
+			}
+
+			rowName := rowTypeName(rowPrefix(repo), query)
+
+			if existing, ok := seen[rowName]; ok {
+				return &parser.SrcError{
+					Token: query.Token,
+					Code:  parser.CodeDuplicate,
+					Inner: fmt.Errorf(
+						"%w: %v of %v.%v is also generated for %v",
+						ErrConflictingRows,
+						rowName,
+						repo.Name,
+						query.Name,
+						existing,
+					),
+				}
+			}
+
+			seen[rowName] = repo.Name + "." + query.Name
+		}
+	}
+
+	return nil
+}
+
+func rowKeys(queries []*parser.Query) map[string]bool {
+	set := make(map[string]bool, len(queries))
+
+	for _, query := range queries {
+		set[rowKey(rowTypeName("", query), query)] = true
+	}
+
+	return set
+}
+
+// Orphans returns the files previously generated into the output directory which are no longer part of the rendered
+// files, such as those of a renamed or removed repository. Only files carrying the generated header are returned.
+func Orphans(outPath string, opts Options, files map[string][]byte) ([]string, error) {
+	var patterns []string
+
+	switch opts.Layout {
+	case LayoutFiles:
+		patterns = []string{filepath.Join(outPath, "*.gen.go")}
+	case LayoutPackages:
+		patterns = []string{filepath.Join(outPath, rowsFile), filepath.Join(outPath, "*", "*.gen.go")}
+	default:
+		return nil, nil
+	}
+
+	header := []byte("// Code generated by " + cuttlePkg + ".")
+
+	var orphans []string
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			if _, ok := files[match]; ok {
+				continue
+			}
+
+			data, err := os.ReadFile(match)
+			if err != nil {
+				return nil, err
+			}
+
+			if bytes.HasPrefix(data, header) {
+				orphans = append(orphans, match)
+			}
+		}
+	}
+
+	return orphans, nil
+}
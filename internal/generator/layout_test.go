@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestOrphans(t *testing.T) {
+	header := "// Code generated by " + cuttlePkg + ". DO NOT EDIT\n\npackage db\n"
+
+	tests := []struct {
+		name     string
+		layout   string
+		existing map[string]string
+		rendered []string
+		want     []string
+	}{
+		{
+			name:   "file",
+			layout: LayoutFile,
+			existing: map[string]string{
+				"old.gen.go": header,
+			},
+			want: nil,
+		},
+		{
+			name:   "files",
+			layout: LayoutFiles,
+			existing: map[string]string{
+				"users_repository.gen.go": header,
+				"old_repository.gen.go":   header,
+				"handwritten.gen.go":      "package db\n",
+				"nested/old.gen.go":       header,
+			},
+			rendered: []string{"users_repository.gen.go", "posts_repository.gen.go"},
+			want:     []string{"old_repository.gen.go"},
+		},
+		{
+			name:   "packages",
+			layout: LayoutPackages,
+			existing: map[string]string{
+				"rows.gen.go": header,
+				"usersrepository/users_repository.gen.go":    header,
+				"oldrepository/old_repository.gen.go":        header,
+				"oldrepository/handwritten.go":               header,
+				"oldrepository/nested/old_repository.gen.go": header,
+			},
+			rendered: []string{"usersrepository/users_repository.gen.go"},
+			want:     []string{"rows.gen.go", "oldrepository/old_repository.gen.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			for name, data := range tt.existing {
+				path := filepath.Join(dir, name)
+
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			files := make(map[string][]byte)
+
+			for _, name := range tt.rendered {
+				files[filepath.Join(dir, name)] = []byte(header)
+			}
+
+			got, err := Orphans(dir, Options{Layout: tt.layout}, files)
+			if err != nil {
+				t.Fatalf("Orphans() failed: %v", err)
+			}
+
+			var want []string
+
+			for _, name := range tt.want {
+				want = append(want, filepath.Join(dir, name))
+			}
+
+			slices.Sort(got)
+			slices.Sort(want)
+
+			if !slices.Equal(got, want) {
+				t.Errorf("Orphans() = %v, want %v", got, want)
+			}
+		})
+	}
+}
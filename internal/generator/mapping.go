@@ -0,0 +1,232 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/csnewman/cuttle/internal/parser"
+	"github.com/dave/jennifer/jen"
+	"github.com/iancoleman/strcase"
+)
+
+// TypeMapping converts a Go type declared by args and cols to a type supported by the driver of a dialect, such as
+// storing a UUID as text in SQLite.
+type TypeMapping struct {
+	// Type is the Go type used by the generated methods.
+	Type *parser.GoType
+	// DBType is the type bound and scanned by the driver.
+	DBType *parser.GoType
+	// Encode is a function of the form func(Type) DBType.
+	Encode *parser.GoType
+	// Decode is a function of the form func(DBType) (Type, error).
+	Decode *parser.GoType
+}
+
+// dialectMapping is a type mapping applying to one of the dialects of a repository.
+type dialectMapping struct {
+	// index is the index of the dialect within the repository
+	index   int
+	dialect string
+	mapping *TypeMapping
+}
+
+// typeMappings returns the mappings applying to a value of the given type for each dialect of the repository. List and
+// JSON values are never mapped, as they are converted by cuttle.
+func (g *Generator) typeMappings(repo *parser.Repository, ty *parser.GoType, list bool, json bool) []dialectMapping {
+	if list || json {
+		return nil
+	}
+
+	var mappings []dialectMapping
+
+	for i, dialect := range repo.Dialects {
+		for _, m := range g.mappings[dialect] {
+			if m.Type.String() == ty.String() {
+				mappings = append(mappings, dialectMapping{
+					index:   i,
+					dialect: dialect,
+					mapping: m,
+				})
+
+				break
+			}
+		}
+	}
+
+	return mappings
+}
+
+func argVarName(i int) string {
+	return fmt.Sprintf("cuttleArg%v", i)
+}
+
+// generateArgConversions declares a variable for each mapped arg, holding the value bound for the selected dialect. The
+// value of each arg is given by value.
+func (g *Generator) generateArgConversions(
+	jg *jen.Group,
+	repo *parser.Repository,
+	query *parser.Query,
+	value func(*parser.Arg) *jen.Statement,
+) {
+	for i, arg := range query.Args {
+		mappings := g.typeMappings(repo, arg.GoType, arg.List, arg.JSON)
+		if len(mappings) == 0 {
+			continue
+		}
+
+		jg.Var().Id(argVarName(i)).Id("any").Op("=").Add(wrapArg(arg, value(arg)))
+
+		var cases []jen.Code
+
+		for _, dm := range mappings {
+			encode := goType(dm.mapping.Encode)
+
+			encoded := encode.Clone().Call(value(arg))
+
+			if arg.Nullable {
+				encoded = jen.Qual(cuttlePkg, "Null").Types(goType(dm.mapping.DBType)).Values(jen.Dict{
+					jen.Id("V"):     encode.Clone().Call(value(arg).Dot("V")),
+					jen.Id("Valid"): value(arg).Dot("Valid"),
+				})
+			}
+
+			cases = append(cases, jen.Case(jen.Lit(dm.index)).Block(
+				jen.Id(argVarName(i)).Op("=").Add(encoded),
+			))
+		}
+
+		jg.Switch(jen.Id("r").Dot("dialectIndex")).Block(cases...)
+	}
+}
+
+func (g *Generator) hasArgConversions(repo *parser.Repository, query *parser.Query) bool {
+	for _, arg := range query.Args {
+		if len(g.typeMappings(repo, arg.GoType, arg.List, arg.JSON)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// argBinding returns the value bound for an arg, given the value of the arg.
+func (g *Generator) argBinding(
+	repo *parser.Repository,
+	i int,
+	arg *parser.Arg,
+	value func(*parser.Arg) *jen.Statement,
+) jen.Code {
+	if len(g.typeMappings(repo, arg.GoType, arg.List, arg.JSON)) > 0 {
+		return jen.Id(argVarName(i))
+	}
+
+	return wrapArg(arg, value(arg))
+}
+
+func scanDestName(i int) string {
+	return fmt.Sprintf("cuttleDest%v", i)
+}
+
+func mappedTempName(i int, dialect string) string {
+	return scanTempName(i) + strcase.ToCamel(dialect)
+}
+
+// declareMappedTemps declares the temporaries that mapped columns are scanned into, along with a destination selecting
+// the temporary of the current dialect.
+func (g *Generator) declareMappedTemps(
+	jg *jen.Group,
+	repo *parser.Repository,
+	query *parser.Query,
+	field func(int) *jen.Statement,
+) {
+	for i, col := range query.Cols {
+		mappings := g.typeMappings(repo, col.GoType, false, col.JSON)
+		if len(mappings) == 0 {
+			continue
+		}
+
+		var cases []jen.Code
+
+		for _, dm := range mappings {
+			jg.Var().Id(mappedTempName(i, dm.dialect)).Add(nullableType(dm.mapping.DBType, col.Nullable))
+
+			cases = append(cases, jen.Case(jen.Lit(dm.index)).Block(
+				jen.Id(scanDestName(i)).Op("=").Op("&").Id(mappedTempName(i, dm.dialect)),
+			))
+		}
+
+		jg.Var().Id(scanDestName(i)).Id("any").Op("=").Op("&").Add(field(i))
+		jg.Switch(jen.Id("r").Dot("dialectIndex")).Block(cases...)
+	}
+}
+
+// decodeMapped returns the statements decoding the temporaries of mapped columns into their fields. Synchronous
+// methods return decoding errors, while asynchronous methods assign them to err for the callback.
+func (g *Generator) decodeMapped(
+	repo *parser.Repository,
+	query *parser.Query,
+	field func(int) *jen.Statement,
+	async bool,
+) []jen.Code {
+	var decode []jen.Code
+
+	for i, col := range query.Cols {
+		mappings := g.typeMappings(repo, col.GoType, false, col.JSON)
+		if len(mappings) == 0 {
+			continue
+		}
+
+		var cases []jen.Code
+
+		for _, dm := range mappings {
+			temp := jen.Id(mappedTempName(i, dm.dialect))
+			target := field(i)
+			value := temp.Clone()
+
+			if col.Nullable {
+				target = target.Dot("V")
+				value = value.Dot("V")
+			}
+
+			call := goType(dm.mapping.Decode).Call(value)
+
+			var body []jen.Code
+
+			if async {
+				body = append(body, jen.List(target, jen.Err()).Op("=").Add(call))
+			} else {
+				body = append(body,
+					jen.Var().Err().Error(),
+					jen.If(
+						jen.List(target, jen.Err()).Op("=").Add(call),
+						jen.Err().Op("!=").Nil(),
+					).Block(jen.Return(jen.Err())),
+				)
+			}
+
+			if col.Nullable {
+				body = append(body, field(i).Dot("Valid").Op("=").True())
+			}
+
+			cond := jen.Id("err").Op("==").Nil()
+
+			switch {
+			case async && col.Nullable:
+				cond = cond.Op("&&").Add(temp.Clone().Dot("Valid"))
+			case col.Nullable:
+				cond = temp.Clone().Dot("Valid")
+			case !async:
+				cond = nil
+			}
+
+			if cond != nil {
+				body = []jen.Code{jen.If(cond).Block(body...)}
+			}
+
+			cases = append(cases, jen.Case(jen.Lit(dm.index)).Block(body...))
+		}
+
+		decode = append(decode, jen.Switch(jen.Id("r").Dot("dialectIndex")).Block(cases...))
+	}
+
+	return decode
+}
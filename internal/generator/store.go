@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/parser"
+	"github.com/dave/jennifer/jen"
+)
+
+// storeName returns the name of the store wrapping a repository, such as UsersStore for UsersRepository.
+func storeName(repo *parser.Repository) string {
+	return strings.TrimSuffix(repo.Name, "Repository") + "Store"
+}
+
+// generateStore emits a struct holding a cuttle.DB, whose methods run each query of the repository within a new
+// transaction of the type the query requires, or within a transaction bound using WithTx. Stores bound to a read
+// transaction fail queries that write with cuttle.ErrReadOnlyTx.
+func (g *Generator) generateStore(repo *parser.Repository) {
+	name := storeName(repo)
+
+	g.file.Line()
+	g.file.Commentf("%v runs the queries of %v, opening a transaction per call unless bound using WithTx.", name, repo.Name)
+	g.file.Type().Id(name).StructFunc(func(jg *jen.Group) {
+		jg.Id("repo").Id(repo.Name)
+		jg.Id("db").Qual(cuttlePkg, "DB")
+		jg.Id("tx").Qual(cuttlePkg, "RTxFuncer")
+	})
+
+	g.file.Line()
+	g.file.Func().Id("New"+name).Params(jen.Id("db").Qual(cuttlePkg, "DB")).Params(
+		jen.Op("*").Id(name),
+		jen.Error(),
+	).BlockFunc(func(jg *jen.Group) {
+		jg.List(jen.Id("repo"), jen.Err()).Op(":=").Id("New" + repo.Name).Call(jen.Id("db").Dot("Dialect").Call())
+		jg.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err()))
+		jg.Line()
+		jg.Return(
+			jen.Op("&").Id(name).Values(jen.Dict{
+				jen.Id("repo"): jen.Id("repo"),
+				jen.Id("db"):   jen.Id("db"),
+			}),
+			jen.Nil(),
+		)
+	})
+
+	g.file.Line()
+	g.file.Comment("WithTx returns a copy of the store running its queries within tx, instead of opening new transactions.")
+	g.file.Comment("Queries that write return cuttle.ErrReadOnlyTx when tx is not a cuttle.WTxFuncer.")
+	g.file.Func().Params(jen.Id("r").Op("*").Id(name)).Id("WithTx").
+		Params(jen.Id("tx").Qual(cuttlePkg, "RTxFuncer")).
+		Op("*").Id(name).
+		Block(jen.Return(jen.Op("&").Id(name).Values(jen.Dict{
+			jen.Id("repo"): jen.Id("r").Dot("repo"),
+			jen.Id("db"):   jen.Id("r").Dot("db"),
+			jen.Id("tx"):   jen.Id("tx"),
+		})))
+
+	for _, query := range repo.Queries {
+		g.generateStoreQuery(name, query)
+	}
+}
+
+func (g *Generator) generateStoreQuery(name string, query *parser.Query) {
+	txType := queryTxType(query)
+
+	call := func(tx jen.Code) *jen.Statement {
+		return jen.Id("r").Dot("repo").Dot(query.Name).CallFunc(func(jg *jen.Group) {
+			jg.Id("ctx")
+			jg.Add(tx)
+
+			for _, name := range inputNames(query) {
+				jg.Add(name)
+			}
+		})
+	}
+
+	g.file.Line()
+	docComment(g.file.Group, query.Doc)
+	g.file.Func().Params(jen.Id("r").Op("*").Id(name)).Id(query.Name).
+		ParamsFunc(func(jg *jen.Group) {
+			jg.Line().Id("ctx").Qual("context", "Context")
+			g.inputParams(query)(jg)
+			jg.Line()
+		}).
+		ParamsFunc(g.queryResults(query)).
+		BlockFunc(func(jg *jen.Group) {
+			if txType == "RTx" {
+				jg.If(jen.Id("r").Dot("tx").Op("!=").Nil()).Block(
+					jen.Return(call(jen.Id("r").Dot("tx"))),
+				)
+				jg.Line()
+			}
+
+			jg.Var().Id("cuttleResValue").Add(g.queryResultType(query))
+			jg.Line()
+
+			if txType == "WTx" {
+				// A bound read transaction can not run queries that write
+				jg.If(jen.Id("r").Dot("tx").Op("!=").Nil()).BlockFunc(func(jg *jen.Group) {
+					jg.List(jen.Id("tx"), jen.Id("ok")).Op(":=").Id("r").Dot("tx").Assert(jen.Qual(cuttlePkg, "WTxFuncer"))
+					jg.If(jen.Op("!").Id("ok")).Block(
+						jen.Return(jen.Id("cuttleResValue"), jen.Qual(cuttlePkg, "ErrReadOnlyTx")),
+					)
+					jg.Line()
+					jg.Return(call(jen.Id("tx")))
+				})
+				jg.Line()
+			}
+
+			jg.Id("cuttleErr").Op(":=").Id("r").Dot("db").Dot(txType).Call(
+				jen.Id("ctx"),
+				jen.Func().Params(
+					jen.Id("ctx").Qual("context", "Context"),
+					jen.Id("tx").Qual(cuttlePkg, txType),
+				).Error().Block(
+					jen.Var().Id("cuttleErr").Error(),
+					jen.Line(),
+					jen.List(jen.Id("cuttleResValue"), jen.Id("cuttleErr")).Op("=").Add(call(jen.Id("tx"))),
+					jen.Line(),
+					jen.Return(jen.Id("cuttleErr")),
+				),
+			)
+			jg.Line()
+
+			jg.Return(jen.Id("cuttleResValue"), jen.Id("cuttleErr"))
+		})
+}
@@ -0,0 +1,18 @@
+-- :cuttle version=1
+
+-- :repository name=AccountsRepository dialects=postgres,sqlite
+-- :query name=GetAccount mode=queryRow
+-- :arg name=id type=example.com/app/model.ID
+-- :col name=id type=example.com/app/model.ID
+-- :col name=parent type=example.com/app/model.ID?
+SELECT id, parent FROM accounts WHERE id = :id;
+
+-- :query name=ListAccounts mode=queryMany
+-- :arg name=ids type=example.com/app/model.ID list=true
+-- :col name=id type=example.com/app/model.ID
+SELECT id FROM accounts WHERE id IN (:ids);
+
+-- :query name=CreateAccount mode=exec
+-- :arg name=id type=example.com/app/model.ID
+-- :arg name=parent type=example.com/app/model.ID nullable=true
+INSERT INTO accounts (id, parent) VALUES (:id, :parent);
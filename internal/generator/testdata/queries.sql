@@ -0,0 +1,65 @@
+-- :cuttle version=1
+
+-- :repository name=UsersRepository dialects=postgres,sqlite
+-- :doc
+-- UsersRepository provides access to user accounts.
+-- :end
+
+-- :fragment name=user_columns
+id, username, role, created_at
+-- :end
+
+-- :query name=GetUser mode=queryRow
+-- :doc
+-- GetUser returns a user by id.
+-- :end
+-- :arg name=id type=int64
+-- :col name=id type=int64
+-- :col name=username type=string
+-- :col name=role type=string?
+-- :col name=createdAt type=time.Time
+SELECT {{user_columns}} FROM users WHERE id = :id;
+
+-- :query name=CountUsers mode=queryRow
+-- :col name=count type=int64
+SELECT count(*) FROM users;
+
+-- :query name=ListUsernames mode=queryMany
+-- :arg name=ids type=int64 list=true
+-- :arg name=role type=string nullable=true
+-- :col name=username type=string
+SELECT username FROM users WHERE id IN (:ids) AND role IS :role;
+
+-- :query name=GetAddress mode=queryRow
+-- :arg name=id type=int64
+-- :col name=address type=net/netip.Addr nullable=true
+SELECT address FROM users WHERE id = @id;
+
+-- :query name=DeleteUser mode=exec
+-- :arg name=id type=int64
+-- :dialect name=postgres
+DELETE FROM users WHERE id = $1;
+-- :dialect name=sqlite
+DELETE FROM users WHERE id = ?1;
+
+-- :query name=CreateUser mode=execRow
+-- :arg name=username type=string
+-- :arg name=meta type=example.com/app/model.Meta json=true
+-- :col name=id type=int64
+-- :col name=createdAt type=time.Time
+INSERT INTO users (username, meta) VALUES (:username, :meta) RETURNING id, created_at;
+
+-- :query name=DisableUsers mode=execMany
+-- :arg name=role type=string
+-- :col name=id type=int64
+UPDATE users SET disabled = true WHERE role = :role RETURNING id;
+
+-- :query name=ListMeta mode=queryMany write=true
+-- :col name=id type=int64
+-- :col name=meta type=*example.com/app/model.Meta json=true
+SELECT id, meta FROM users FOR UPDATE;
+
+-- :query name=CopyUsers mode=copy table=users
+-- :arg name=username type=string
+-- :arg name=role type=string?
+-- :arg name=createdAt type=time.Time column=created
@@ -0,0 +1,29 @@
+-- :cuttle version=1
+
+-- :repository name=UsersRepository dialects=sqlite
+-- :query name=Get mode=queryRow
+-- :arg name=id type=int64
+-- :col name=id type=int64
+-- :col name=name type=string
+SELECT id, name FROM users WHERE id = :id;
+
+-- :query name=ListEmails mode=queryMany
+-- :col name=id type=int64
+-- :col name=email type=string?
+SELECT id, email FROM users;
+
+-- :query name=Rename mode=exec
+-- :arg name=id type=int64
+-- :arg name=name type=string
+UPDATE users SET name = :name WHERE id = :id;
+
+-- :repository name=PostsRepository dialects=sqlite
+-- :query name=Get mode=queryRow
+-- :arg name=id type=int64
+-- :col name=id type=int64
+-- :col name=name type=string
+SELECT id, title AS name FROM posts WHERE id = :id;
+
+-- :query name=CopyPosts mode=copy table=posts
+-- :arg name=title type=string
+-- :arg name=authorId type=int64
@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"github.com/csnewman/cuttle/internal/parser"
+	"github.com/dave/jennifer/jen"
+)
+
+func goType(ty *parser.GoType) *jen.Statement {
+	switch ty.Kind {
+	case parser.GoTypeKindNamed:
+		var s *jen.Statement
+
+		if ty.Path == "" {
+			s = jen.Id(ty.Name)
+		} else {
+			s = jen.Qual(ty.Path, ty.Name)
+		}
+
+		if len(ty.TypeArgs) > 0 {
+			s = s.TypesFunc(func(jg *jen.Group) {
+				for _, arg := range ty.TypeArgs {
+					jg.Add(goType(arg))
+				}
+			})
+		}
+
+		return s
+	case parser.GoTypeKindPointer:
+		return jen.Op("*").Add(goType(ty.Elem))
+	case parser.GoTypeKindSlice:
+		return jen.Index().Add(goType(ty.Elem))
+	case parser.GoTypeKindArray:
+		return jen.Index(jen.Id(ty.Len)).Add(goType(ty.Elem))
+	case parser.GoTypeKindMap:
+		return jen.Map(goType(ty.Key)).Add(goType(ty.Elem))
+	default:
+		panic("unexpected " + ty.Kind)
+	}
+}
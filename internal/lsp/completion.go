@@ -0,0 +1,119 @@
+package lsp
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/generator"
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+// completion suggests directive names after "-- :", keys after the directive name and values after "key=".
+func (s *Server) completion(params *TextDocumentPositionParams) ([]CompletionItem, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	line, offset := doc.offset(params.Position)
+
+	rest, ok := directivePrefix(line[:offset])
+	if !ok {
+		return []CompletionItem{}, nil
+	}
+
+	name, args, hasArgs := strings.Cut(rest, " ")
+	if !hasArgs {
+		return directiveItems(), nil
+	}
+
+	ty := parser.DirectiveType(strings.ToLower(name))
+	word := args[strings.LastIndexAny(args, " \t")+1:]
+
+	key, _, hasValue := strings.Cut(word, "=")
+	if hasValue {
+		return valueItems(ty, key), nil
+	}
+
+	return keyItems(ty, args), nil
+}
+
+// directivePrefix returns the text following "-- :" on a directive line.
+func directivePrefix(line string) (string, bool) {
+	rest := strings.TrimLeft(line, " \t")
+
+	rest, ok := strings.CutPrefix(rest, "--")
+	if !ok {
+		return "", false
+	}
+
+	return strings.CutPrefix(strings.TrimLeft(rest, " \t"), ":")
+}
+
+func directiveItems() []CompletionItem {
+	items := make([]CompletionItem, 0, len(parser.DirectiveKeys))
+
+	for ty := range parser.DirectiveKeys {
+		items = append(items, CompletionItem{
+			Label: string(ty),
+			Kind:  CompletionItemKindKeyword,
+		})
+	}
+
+	sortItems(items)
+
+	return items
+}
+
+// keyItems suggests the keys of a directive that have not already been provided.
+func keyItems(ty parser.DirectiveType, args string) []CompletionItem {
+	items := []CompletionItem{}
+
+	for _, key := range parser.DirectiveKeys[ty] {
+		if strings.HasPrefix(args, key+"=") || strings.Contains(args, " "+key+"=") {
+			continue
+		}
+
+		items = append(items, CompletionItem{
+			Label:      key,
+			Kind:       CompletionItemKindProperty,
+			InsertText: key + "=",
+		})
+	}
+
+	return items
+}
+
+func valueItems(ty parser.DirectiveType, key string) []CompletionItem {
+	var values []string
+
+	switch {
+	case ty == parser.DirectiveTypeQuery && key == "mode":
+		for mode := range parser.ModeValues {
+			values = append(values, mode)
+		}
+	case ty == parser.DirectiveTypeRepository && key == "dialects", ty == parser.DirectiveTypeDialect && key == "name":
+		values = generator.Dialects()
+	case key == "infer", key == "write", key == "nullable", key == "json", key == "list":
+		values = []string{"true", "false"}
+	}
+
+	items := make([]CompletionItem, 0, len(values))
+
+	for _, value := range values {
+		items = append(items, CompletionItem{
+			Label: value,
+			Kind:  CompletionItemKindValue,
+		})
+	}
+
+	sortItems(items)
+
+	return items
+}
+
+func sortItems(items []CompletionItem) {
+	slices.SortFunc(items, func(a CompletionItem, b CompletionItem) int {
+		return strings.Compare(a.Label, b.Label)
+	})
+}
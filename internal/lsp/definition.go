@@ -0,0 +1,310 @@
+package lsp
+
+import (
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/generator"
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+const generatedHeader = "// Code generated by github.com/csnewman/cuttle."
+
+// definition resolves identifiers within generated Go files, such as repository methods and row types, to the sql
+// directive that they were generated from.
+func (s *Server) definition(params *TextDocumentPositionParams) ([]Location, error) {
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		path := uriToPath(params.TextDocument.URI)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		doc = newDocument(params.TextDocument.URI, string(data))
+	}
+
+	if filepath.Ext(doc.path) != ".go" || !strings.Contains(doc.text, generatedHeader) {
+		return []Location{}, nil
+	}
+
+	line, offset := doc.offset(params.Position)
+
+	ident := identAt(line, offset)
+	if ident == "" {
+		return []Location{}, nil
+	}
+
+	root := s.root
+	if root == "" {
+		root = filepath.Dir(doc.path)
+	}
+
+	// Only repositories generated into this file are candidates, narrowed to the repository of the enclosing method or
+	// interface when there is one, as repositories commonly share query names
+	declared := declaredTypes(doc.lines)
+	enclosing := enclosingType(doc.lines, params.Position.Line)
+
+	locations := []Location{}
+
+	// Files imported by several files are parsed repeatedly, so duplicate locations are removed
+	add := func(tk *parser.Token) {
+		location := s.location(tk)
+
+		if !slices.Contains(locations, location) {
+			locations = append(locations, location)
+		}
+	}
+
+	units := s.workspaceUnits(root)
+
+	// owners maps the types generated for each repository to the name of the repository
+	owners := make(map[string]string)
+
+	for _, unit := range units {
+		for _, repo := range unit.Repositories {
+			for _, name := range generator.TypeNames(repo) {
+				owners[name] = repo.Name
+			}
+		}
+	}
+
+	owner, hasOwner := owners[enclosing]
+
+	// Row types are only prefixed with their repository when not generated into a package per repository
+	layouts := make(map[string]string)
+	layoutOf := func(path string) string {
+		layout, ok := layouts[path]
+		if !ok {
+			layout = s.layout(path)
+			layouts[path] = layout
+		}
+
+		return layout
+	}
+
+	for _, unit := range units {
+		for _, repo := range unit.Repositories {
+			types := generator.TypeNames(repo)
+
+			if !slices.ContainsFunc(types, func(name string) bool { return declared[name] }) {
+				continue
+			}
+
+			if hasOwner && owner != repo.Name {
+				continue
+			}
+
+			if slices.Contains(types, ident) || slices.Contains(types, strings.TrimPrefix(ident, "New")) {
+				add(repo.Token)
+			}
+
+			for _, query := range repo.Queries {
+				if ident == query.Name || ident == query.Name+"Async" ||
+					ident == generator.RowTypeName(repo, query, layoutOf(query.Token.Source)) {
+					add(query.Token)
+				}
+			}
+		}
+	}
+
+	return locations, nil
+}
+
+// declaredTypes returns the names of the types declared at the top level of a Go file.
+func declaredTypes(lines []string) map[string]bool {
+	types := make(map[string]bool)
+
+	for _, line := range lines {
+		if name, ok := typeDecl(line); ok {
+			types[name] = true
+		}
+	}
+
+	return types
+}
+
+// enclosingType returns the type of the top level declaration containing a line, being either the declared type or
+// the receiver of a method. An empty string is returned for other declarations.
+func enclosingType(lines []string, line int) string {
+	for i := min(line, len(lines)-1); i >= 0; i-- {
+		l := lines[i]
+
+		// Lines within a declaration are indented, or close it
+		if l == "" || l[0] == '\t' || l[0] == ' ' || l[0] == '}' || l[0] == ')' || strings.HasPrefix(l, "//") {
+			continue
+		}
+
+		if name, ok := typeDecl(l); ok {
+			return name
+		}
+
+		if rest, ok := strings.CutPrefix(l, "func ("); ok {
+			receiver, _, _ := strings.Cut(rest, ")")
+
+			fields := strings.Fields(receiver)
+			if len(fields) == 0 {
+				return ""
+			}
+
+			return strings.TrimPrefix(fields[len(fields)-1], "*")
+		}
+
+		return ""
+	}
+
+	return ""
+}
+
+func typeDecl(line string) (string, bool) {
+	rest, ok := strings.CutPrefix(line, "type ")
+	if !ok {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(rest, " ")
+
+	return name, name != ""
+}
+
+// identAt returns the Go identifier surrounding the byte offset.
+func identAt(line string, offset int) string {
+	start := offset
+	for start > 0 && isIdentByte(line[start-1]) {
+		start--
+	}
+
+	end := offset
+	for end < len(line) && isIdentByte(line[end]) {
+		end++
+	}
+
+	return line[start:end]
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// workspaceCache contains the units parsed from the sql files of a workspace, reused until any of the files change.
+// Files may import each other, so a change to any file invalidates every unit.
+type workspaceCache struct {
+	root  string
+	files map[string]fileState
+	units []*parser.Unit
+}
+
+// fileState identifies the contents of a sql file, using the text of open documents, or the size and modification time
+// of files on disk.
+type fileState struct {
+	open    bool
+	text    string
+	size    int64
+	modTime int64
+}
+
+// workspaceUnits parses every cuttle sql file beneath the root, preferring the contents of open documents.
+func (s *Server) workspaceUnits(root string) []*parser.Unit {
+	var paths []string
+
+	files := make(map[string]fileState)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !isSQL(path) {
+			return nil
+		}
+
+		if doc, ok := s.docs[pathToURI(path)]; ok {
+			files[path] = fileState{open: true, text: doc.text}
+		} else {
+			info, err := d.Info()
+			if err != nil {
+				return nil //nolint:nilerr
+			}
+
+			files[path] = fileState{size: info.Size(), modTime: info.ModTime().UnixNano()}
+		}
+
+		paths = append(paths, path)
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Warn("Failed to walk workspace", "root", root, "err", err)
+	}
+
+	if s.workspace != nil && s.workspace.root == root && maps.Equal(s.workspace.files, files) {
+		return s.workspace.units
+	}
+
+	var units []*parser.Unit
+
+	for _, path := range paths {
+		text := files[path].text
+
+		if !files[path].open {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+
+			text = string(data)
+		}
+
+		// Errors are reported as diagnostics, while the successfully parsed queries can still be resolved
+		unit, _ := s.parse(path, text)
+		if unit != nil {
+			units = append(units, unit)
+		}
+	}
+
+	s.workspace = &workspaceCache{
+		root:  root,
+		files: files,
+		units: units,
+	}
+
+	return units
+}
+
+func (s *Server) location(tk *parser.Token) Location {
+	var line string
+
+	if len(tk.RawLines) > 0 {
+		line = tk.RawLines[0]
+	}
+
+	col := min(max(tk.Col-1, 0), len(line))
+
+	return Location{
+		URI: pathToURI(tk.Source),
+		Range: Range{
+			Start: Position{
+				Line:      tk.Start - 1,
+				Character: utf16Len(line[:col]),
+			},
+			End: Position{
+				Line:      tk.Start - 1,
+				Character: utf16Len(line),
+			},
+		},
+	}
+}
@@ -0,0 +1,213 @@
+package lsp
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const definitionSQL = `-- :cuttle version=1
+
+-- :repository name=UsersRepository
+-- :query name=Get mode=queryRow
+-- :col name=id type=int64
+-- :col name=name type=string
+SELECT id, name FROM users;
+
+-- :repository name=PostsRepository
+-- :query name=Get mode=queryRow
+-- :col name=id type=int64
+-- :col name=title type=string
+SELECT id, title FROM posts;
+`
+
+const definitionGo = generatedHeader + ` DO NOT EDIT
+
+package db
+
+type UsersRepository interface {
+	Get(ctx context.Context, tx cuttle.RTxFuncer) (UsersGetRow, error)
+}
+
+type usersRepositoryImpl struct {
+	dialect cuttle.Dialect
+}
+
+type UsersGetRow struct {
+	Id   int64
+	Name string
+}
+
+func (r *usersRepositoryImpl) Get(ctx context.Context, tx cuttle.RTxFuncer) (UsersGetRow, error) {
+	return UsersGetRow{}, nil
+}
+
+type PostsRepository interface {
+	Get(ctx context.Context, tx cuttle.RTxFuncer) (PostsGetRow, error)
+}
+
+func (r *PostsStore) Get(ctx context.Context) (PostsGetRow, error) {
+	return r.repo.Get(ctx, r.tx)
+}
+`
+
+func TestDefinition(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "queries.sql"), []byte(definitionSQL), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	goPath := filepath.Join(dir, "queries.gen.go")
+
+	if err := os.WriteFile(goPath, []byte(definitionGo), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(strings.NewReader(""), io.Discard, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.root = dir
+
+	goLines := strings.Split(definitionGo, "\n")
+
+	// find returns the position of the nth occurrence of ident within the generated file
+	find := func(ident string, n int) Position {
+		for i, line := range goLines {
+			for col := strings.Index(line, ident); col != -1; {
+				if n == 0 {
+					return Position{Line: i, Character: col}
+				}
+
+				n--
+
+				next := strings.Index(line[col+1:], ident)
+				if next == -1 {
+					break
+				}
+
+				col += next + 1
+			}
+		}
+
+		t.Fatalf("%v not found", ident)
+
+		return Position{}
+	}
+
+	tests := []struct {
+		name     string
+		position Position
+		// line is the line of the expected sql directive, counted from 0
+		line int
+	}{
+		{name: "interface method", position: find("Get(", 0), line: 3},
+		{name: "row type", position: find("UsersGetRow", 0), line: 3},
+		{name: "impl method", position: find("Get(", 1), line: 3},
+		{name: "other interface method", position: find("Get(", 2), line: 9},
+		{name: "store method", position: find("Get(", 3), line: 9},
+		{name: "store call", position: find("Get(", 4), line: 9},
+		{name: "repository", position: find("PostsRepository", 0), line: 8},
+		{name: "other row type", position: find("PostsGetRow", 0), line: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locations, err := s.definition(&TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: pathToURI(goPath)},
+				Position:     tt.position,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(locations) != 1 {
+				t.Fatalf("definition() = %+v, want a single location", locations)
+			}
+
+			if got := locations[0].Range.Start.Line; got != tt.line {
+				t.Errorf("definition() line = %v, want %v", got, tt.line)
+			}
+		})
+	}
+
+	units := s.workspaceUnits(dir)
+
+	if cached := s.workspaceUnits(dir); len(cached) != 1 || cached[0] != units[0] {
+		t.Errorf("workspaceUnits() parsed the workspace again without changes")
+	}
+}
+
+func TestDefinitionPackagesLayout(t *testing.T) {
+	dir := t.TempDir()
+
+	config := "version: 1\ntargets:\n  - inputs: [\"*.sql\"]\n    output: db\n    layout: packages\n" +
+		"    import_path: example.com/db\n"
+
+	goText := generatedHeader + ` DO NOT EDIT
+
+package usersrepository
+
+type UsersRepository interface {
+	Get(ctx context.Context, tx cuttle.RTxFuncer) (GetRow, error)
+}
+
+type GetRow struct {
+	Id   int64
+	Name string
+}
+
+type UsersGetRow struct{}
+`
+
+	goPath := filepath.Join(dir, "users_repository.gen.go")
+
+	for path, data := range map[string]string{
+		filepath.Join(dir, "cuttle.yaml"): config,
+		filepath.Join(dir, "queries.sql"): definitionSQL,
+		goPath:                            goText,
+	} {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewServer(strings.NewReader(""), io.Discard, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.root = dir
+
+	lines := strings.Split(goText, "\n")
+
+	tests := []struct {
+		name  string
+		ident string
+		want  int
+	}{
+		{name: "unprefixed row type", ident: "GetRow", want: 1},
+		{name: "prefixed row type", ident: "UsersGetRow", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pos Position
+
+			for i, line := range lines {
+				if strings.HasPrefix(line, "type "+tt.ident+" ") {
+					pos = Position{Line: i, Character: len("type ")}
+				}
+			}
+
+			locations, err := s.definition(&TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: pathToURI(goPath)},
+				Position:     pos,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(locations) != tt.want {
+				t.Errorf("definition() = %+v, want %v locations", locations, tt.want)
+			}
+		})
+	}
+}
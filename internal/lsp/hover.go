@@ -0,0 +1,80 @@
+package lsp
+
+import (
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/generator"
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+// hover shows the Go declarations generated for the query surrounding the cursor.
+func (s *Server) hover(params *TextDocumentPositionParams) (*Hover, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isSQL(doc.path) {
+		return nil, nil
+	}
+
+	// Queries that failed to parse are omitted from the unit, while the remaining queries can still be shown
+	unit, _ := s.parse(doc.path, doc.text)
+	if unit == nil {
+		return nil, nil
+	}
+
+	repo, query := queryAt(unit, doc.path, params.Position.Line+1)
+	if query == nil {
+		return nil, nil
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("```go\n")
+	sb.WriteString(generator.Signature(repo, query, s.layout(doc.path)))
+	sb.WriteString("\n```\n")
+
+	if query.Infer {
+		sb.WriteString("\nColumns are inferred from the schema during generation.\n")
+	}
+
+	if query.Doc != nil {
+		sb.WriteString("\n")
+
+		for _, line := range query.Doc.Text() {
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: sb.String(),
+		},
+	}, nil
+}
+
+// queryAt returns the query declared closest before the given line, counted from 1, ignoring queries imported from
+// other files.
+func queryAt(unit *parser.Unit, path string, line int) (*parser.Repository, *parser.Query) {
+	var (
+		foundRepo  *parser.Repository
+		foundQuery *parser.Query
+	)
+
+	for _, repo := range unit.Repositories {
+		for _, query := range repo.Queries {
+			if query.Token.Source != path || query.Token.Start > line {
+				continue
+			}
+
+			if foundQuery == nil || query.Token.Start > foundQuery.Token.Start {
+				foundRepo = repo
+				foundQuery = query
+			}
+		}
+	}
+
+	return foundRepo, foundQuery
+}
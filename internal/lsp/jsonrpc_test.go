@@ -0,0 +1,92 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConnRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		method string
+		err    error
+		code   int
+	}{
+		{
+			name:   "valid",
+			in:     "Content-Length: 19\r\nContent-Type: application/vscode-jsonrpc\r\n\r\n{\"method\":\"exit\"}\n\n",
+			method: "exit",
+		},
+		{
+			name:   "header case",
+			in:     "content-length: 17\r\n\r\n{\"method\":\"exit\"}",
+			method: "exit",
+		},
+		{
+			name: "missing length",
+			in:   "Content-Type: application/vscode-jsonrpc\r\n\r\n{}",
+			err:  ErrInvalidHeader,
+		},
+		{
+			name: "invalid length",
+			in:   "Content-Length: two\r\n\r\n{}",
+			err:  ErrInvalidHeader,
+		},
+		{
+			name: "negative length",
+			in:   "Content-Length: -1\r\n\r\n{}",
+			err:  ErrInvalidHeader,
+		},
+		{
+			name: "truncated body",
+			in:   "Content-Length: 10\r\n\r\n{}",
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name: "invalid body",
+			in:   "Content-Length: 9\r\n\r\n{\"id\": 1,",
+			code: codeParseError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := newConn(strings.NewReader(tt.in), io.Discard).read()
+
+			var re *responseError
+
+			switch {
+			case tt.code != 0:
+				if !errors.As(err, &re) || re.Code != tt.code {
+					t.Errorf("read() = %+v, %v, want code %v", msg, err, tt.code)
+				}
+			case tt.err != nil:
+				if !errors.Is(err, tt.err) {
+					t.Errorf("read() = %+v, %v, want %v", msg, err, tt.err)
+				}
+			case err != nil:
+				t.Errorf("read() failed: %v", err)
+			case msg.Method != tt.method:
+				t.Errorf("Method = %v, want %v", msg.Method, tt.method)
+			}
+		})
+	}
+}
+
+func TestConnReplyNullID(t *testing.T) {
+	var out bytes.Buffer
+
+	err := newConn(strings.NewReader(""), &out).reply(nil, nil, &responseError{Code: codeParseError, Message: "bad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Content-Length: 67\r\n\r\n" + `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"bad"}}`
+	if got := out.String(); got != want {
+		t.Errorf("reply() wrote %q, want %q", got, want)
+	}
+}
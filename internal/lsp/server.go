@@ -0,0 +1,415 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/csnewman/cuttle/internal/config"
+	"github.com/csnewman/cuttle/internal/generator"
+	"github.com/csnewman/cuttle/internal/parser"
+)
+
+var (
+	ErrExitWithoutShutdown = errors.New("exit received before shutdown")
+	errExit                = errors.New("exit")
+	errUnknownDocument     = errors.New("unknown document")
+)
+
+type document struct {
+	uri   string
+	path  string
+	text  string
+	lines []string
+}
+
+func newDocument(uri string, text string) *document {
+	lines := strings.Split(text, "\n")
+
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return &document{
+		uri:   uri,
+		path:  uriToPath(uri),
+		text:  text,
+		lines: lines,
+	}
+}
+
+// Server is a language server for cuttle sql files, communicating over a pair of streams such as stdin and stdout.
+type Server struct {
+	conn     *conn
+	logger   *slog.Logger
+	root     string
+	docs     map[string]*document
+	shutdown bool
+	// workspace caches the units resolved by definition requests
+	workspace *workspaceCache
+}
+
+func NewServer(in io.Reader, out io.Writer, logger *slog.Logger) *Server {
+	return &Server{
+		conn:   newConn(in, out),
+		logger: logger,
+		docs:   make(map[string]*document),
+	}
+}
+
+// Run handles messages until the client requests the server to exit. An error is returned if the connection fails or
+// the client exits without first requesting a shutdown.
+func (s *Server) Run() error {
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			var re *responseError
+
+			if errors.As(err, &re) {
+				if err := s.conn.reply(nil, nil, re); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			return err
+		}
+
+		err = s.handle(msg)
+		if errors.Is(err, errExit) {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) error {
+	s.logger.Debug("Handling message", "method", msg.Method)
+
+	var (
+		result any
+		err    error
+	)
+
+	switch msg.Method {
+	case "initialize":
+		result, err = handleRequest(msg, s.initialize)
+	case "initialized":
+	case "shutdown":
+		s.shutdown = true
+	case "exit":
+		return errExit
+	case "textDocument/didOpen":
+		_, err = handleRequest(msg, s.didOpen)
+	case "textDocument/didChange":
+		_, err = handleRequest(msg, s.didChange)
+	case "textDocument/didClose":
+		_, err = handleRequest(msg, s.didClose)
+	case "textDocument/completion":
+		result, err = handleRequest(msg, s.completion)
+	case "textDocument/hover":
+		result, err = handleRequest(msg, s.hover)
+	case "textDocument/definition":
+		result, err = handleRequest(msg, s.definition)
+	default:
+		if msg.ID != nil {
+			err = &responseError{
+				Code:    codeMethodNotFound,
+				Message: "method not found: " + msg.Method,
+			}
+		}
+	}
+
+	// Notifications do not receive a response, so failures can only be logged
+	if msg.ID == nil {
+		if err != nil {
+			s.logger.Warn("Failed to handle notification", "method", msg.Method, "err", err)
+		}
+
+		return nil
+	}
+
+	return s.conn.reply(msg.ID, result, err)
+}
+
+// handleRequest decodes the params of a message before calling the handler.
+func handleRequest[P any, R any](msg *message, handler func(*P) (R, error)) (R, error) {
+	var params P
+
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		var zero R
+
+		return zero, &responseError{
+			Code:    codeInvalidParams,
+			Message: err.Error(),
+		}
+	}
+
+	return handler(&params)
+}
+
+func (s *Server) initialize(params *InitializeParams) (*InitializeResult, error) {
+	if len(params.WorkspaceFolders) > 0 {
+		s.root = uriToPath(params.WorkspaceFolders[0].URI)
+	} else if params.RootURI != "" {
+		s.root = uriToPath(params.RootURI)
+	}
+
+	s.logger.Info("Initializing", "root", s.root)
+
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync: textDocumentSyncFull,
+			CompletionProvider: &CompletionOptions{
+				TriggerCharacters: []string{":", " ", "=", ","},
+			},
+			HoverProvider:      true,
+			DefinitionProvider: true,
+		},
+		ServerInfo: ServerInfo{
+			Name: "cuttle-lsp",
+		},
+	}, nil
+}
+
+func (s *Server) didOpen(params *DidOpenTextDocumentParams) (any, error) {
+	doc := newDocument(params.TextDocument.URI, params.TextDocument.Text)
+	s.docs[doc.uri] = doc
+
+	return nil, s.publishDiagnostics(doc)
+}
+
+func (s *Server) didChange(params *DidChangeTextDocumentParams) (any, error) {
+	if len(params.ContentChanges) == 0 {
+		return nil, nil
+	}
+
+	// Full synchronisation is used, so the last change contains the entire document
+	doc := newDocument(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	s.docs[doc.uri] = doc
+
+	return nil, s.publishDiagnostics(doc)
+}
+
+func (s *Server) didClose(params *DidCloseTextDocumentParams) (any, error) {
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+
+	delete(s.docs, doc.uri)
+
+	if !isSQL(doc.path) {
+		return nil, nil
+	}
+
+	return nil, s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// parse parses a sql document. A nil unit is returned if the document is not a cuttle file.
+func (s *Server) parse(path string, text string) (*parser.Unit, error) {
+	unit, err := parser.Parse(strings.NewReader(text), path, s.logger)
+
+	// Files without a cuttle directive are plain sql
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	return unit, err
+}
+
+// layout returns the layout of the target generating the sql file at path, as configured by the cuttle.yaml at the
+// root of the workspace, defaulting to generator.LayoutFile.
+func (s *Server) layout(path string) string {
+	root := s.root
+	if root == "" {
+		root = filepath.Dir(path)
+	}
+
+	cfg, err := config.Read(filepath.Join(root, config.DefaultPath))
+	if err != nil {
+		return generator.LayoutFile
+	}
+
+	if t := cfg.TargetOf(path); t != nil && t.Layout != "" {
+		return t.Layout
+	}
+
+	return generator.LayoutFile
+}
+
+func (s *Server) publishDiagnostics(doc *document) error {
+	if !isSQL(doc.path) {
+		return nil
+	}
+
+	diagnostics := []Diagnostic{}
+
+	unit, err := s.parse(doc.path, doc.text)
+
+	if unit != nil {
+		for _, w := range unit.Warnings {
+			// Warnings within imported files are reported when those files are opened
+			if w.Token.Source == doc.path {
+				diagnostics = append(diagnostics, doc.diagnostic(w))
+			}
+		}
+	}
+
+	for _, err := range flattenErrors(err) {
+		var el *parser.SrcError
+
+		if errors.As(err, &el) && el.Token != nil {
+			if el.Token.Source == doc.path {
+				diagnostics = append(diagnostics, doc.diagnostic(el))
+			}
+
+			continue
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: DiagnosticSeverityError,
+			Source:   "cuttle",
+			Message:  err.Error(),
+		})
+	}
+
+	return s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Diagnostics: diagnostics,
+	})
+}
+
+func (d *document) diagnostic(err *parser.SrcError) Diagnostic {
+	line, col, endLine, endCol := err.Position()
+
+	severity := DiagnosticSeverityError
+	if err.Severity == parser.SeverityWarning {
+		severity = DiagnosticSeverityWarning
+	}
+
+	return Diagnostic{
+		Range: Range{
+			Start: d.position(line, col),
+			End:   d.position(endLine, endCol),
+		},
+		Severity: severity,
+		Code:     string(err.Code),
+		Source:   "cuttle",
+		Message:  err.Inner.Error(),
+	}
+}
+
+// position converts a line and byte column, both counted from 1, to a protocol position.
+func (d *document) position(line int, col int) Position {
+	if line < 1 || line > len(d.lines) {
+		return Position{
+			Line: max(line-1, 0),
+		}
+	}
+
+	text := d.lines[line-1]
+	col = min(max(col-1, 0), len(text))
+
+	return Position{
+		Line:      line - 1,
+		Character: utf16Len(text[:col]),
+	}
+}
+
+// offset converts a protocol position to the line text and the byte offset of the position within it.
+func (d *document) offset(pos Position) (string, int) {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return "", 0
+	}
+
+	text := d.lines[pos.Line]
+	units := 0
+
+	for i, r := range text {
+		if units >= pos.Character {
+			return text, i
+		}
+
+		// Runes outside the basic multilingual plane are encoded as surrogate pairs
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+
+	return text, len(text)
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error }) //nolint:errorlint
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+
+	for _, err := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(err)...)
+	}
+
+	return errs
+}
+
+func isSQL(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".sql")
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+
+	return (&url.URL{
+		Scheme: "file",
+		Path:   filepath.ToSlash(abs),
+	}).String()
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("%w: document not open: %v", errUnknownDocument, uri)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,165 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const serverSQL = `-- :cuttle version=1
+
+-- :repository name=UsersRepository
+-- :query name=Get mode=queryRow
+-- :arg name=id type=int64
+-- :col name=name type=string
+SELECT name FROM users WHERE id = ?1;
+`
+
+// frame encodes messages with Content-Length headers.
+func frame(bodies ...string) string {
+	var sb strings.Builder
+
+	for _, body := range bodies {
+		fmt.Fprintf(&sb, "Content-Length: %v\r\n\r\n%v", len(body), body)
+	}
+
+	return sb.String()
+}
+
+// runServer runs a server until the client exits, returning the responses keyed by their id and the raw output.
+func runServer(t *testing.T, bodies ...string) (map[string]*message, string) {
+	t.Helper()
+
+	bodies = append(bodies, `{"jsonrpc":"2.0","id":"shutdown","method":"shutdown"}`, `{"jsonrpc":"2.0","method":"exit"}`)
+
+	var out bytes.Buffer
+
+	s := NewServer(strings.NewReader(frame(bodies...)), &out, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := s.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	raw := out.String()
+	responses := make(map[string]*message)
+	c := newConn(&out, io.Discard)
+
+	for {
+		msg, err := c.read()
+		if errors.Is(err, io.EOF) {
+			return responses, raw
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		// Notifications, such as diagnostics, have no id
+		if msg.ID != nil {
+			responses[string(*msg.ID)] = msg
+		}
+	}
+}
+
+// decodeResult decodes the result of a response into v.
+func decodeResult(t *testing.T, msg *message, v any) {
+	t.Helper()
+
+	if msg == nil {
+		t.Fatal("missing response")
+	}
+
+	if msg.Error != nil {
+		t.Fatalf("response failed: %v", msg.Error)
+	}
+
+	raw, err := json.Marshal(msg.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	uri := pathToURI(filepath.Join(dir, "queries.sql"))
+
+	text, err := json.Marshal(serverSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The directive being typed is only added after hovering, as it fails to parse
+	changed, err := json.Marshal(serverSQL + "-- :")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses, _ := runServer(t,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":%q}}`, pathToURI(dir)),
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen",`+
+			`"params":{"textDocument":{"uri":%q,"languageId":"sql","version":1,"text":%s}}}`, uri, text),
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover",`+
+			`"params":{"textDocument":{"uri":%q},"position":{"line":4,"character":3}}}`, uri),
+		fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didChange",`+
+			`"params":{"textDocument":{"uri":%q,"version":2},"contentChanges":[{"text":%s}]}}`, uri, changed),
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":3,"method":"textDocument/completion",`+
+			`"params":{"textDocument":{"uri":%q},"position":{"line":7,"character":4}}}`, uri),
+		`{"jsonrpc":"2.0","id":4,"method":"textDocument/unknown","params":{}}`,
+	)
+
+	var initResult InitializeResult
+
+	decodeResult(t, responses["1"], &initResult)
+
+	if caps := initResult.Capabilities; !caps.HoverProvider || !caps.DefinitionProvider || caps.CompletionProvider == nil {
+		t.Errorf("Capabilities = %+v, want hover, definition and completion", caps)
+	}
+
+	var hover Hover
+
+	decodeResult(t, responses["2"], &hover)
+
+	want := "func (UsersRepository) Get(\n\tctx context.Context,\n\ttx cuttle.RTxFuncer,\n\tid int64,\n) (string, error)"
+	if !strings.Contains(hover.Contents.Value, want) {
+		t.Errorf("hover = %q, want %q", hover.Contents.Value, want)
+	}
+
+	var items []CompletionItem
+
+	decodeResult(t, responses["3"], &items)
+
+	if !containsLabel(items, "query") || !containsLabel(items, "repository") {
+		t.Errorf("completion = %+v, want directives", items)
+	}
+
+	if msg := responses["4"]; msg == nil || msg.Error == nil || msg.Error.Code != codeMethodNotFound {
+		t.Errorf("unknown method response = %+v, want method not found", msg)
+	}
+}
+
+func TestServerInvalidBody(t *testing.T) {
+	_, out := runServer(t, `{"jsonrpc":"2.0","id":1,`)
+
+	want := frame(`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`)
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("Run() wrote %q, want prefix %q", out, want)
+	}
+}
+
+func containsLabel(items []CompletionItem, label string) bool {
+	for _, item := range items {
+		if item.Label == label {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"regexp"
+
+	"github.com/iancoleman/strcase"
+)
+
+// tableName matches a table name, optionally qualified with a schema, such as "users" or "public.users".
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// checkCopy validates a copy query, which inserts a row per element of its input with a column per arg.
+func checkCopy(dir *Directive, query *Query) error {
+	if query.Table == "" {
+		return dir.typeErrorf(CodeMissingKey, "%w: no table provided", ErrInvalidInput)
+	}
+
+	if len(query.Args) == 0 {
+		return dir.errorf(CodeInvalidQuery, "%w: copy queries require at least one arg", ErrInvalidInput)
+	}
+
+	if len(query.Cols) != 0 {
+		return dir.valueErrorf("mode", CodeInvalidQuery, "%w: copy queries can not contain columns", ErrInvalidInput)
+	}
+
+	if query.Infer {
+		return dir.keyErrorf("infer", CodeInvalidQuery, "%w: copy queries can not infer columns", ErrInvalidInput)
+	}
+
+	columns := make(map[string]bool, len(query.Args))
+
+	for _, arg := range query.Args {
+		if arg.List {
+			return wrapSrcError(arg.Token, CodeInvalidQuery, "%w: copy queries can not contain list args", ErrInvalidInput)
+		}
+
+		if arg.Column == "" {
+			arg.Column = strcase.ToSnake(arg.Name)
+		}
+
+		if columns[arg.Column] {
+			return wrapSrcError(arg.Token, CodeDuplicate, "%w: duplicate column %v", ErrInvalidInput, arg.Column)
+		}
+
+		columns[arg.Column] = true
+	}
+
+	return nil
+}
+
+// sqlToken returns the token of the earliest sql within a query, or the query directive if the sql was included.
+func sqlToken(dir *Directive, query *Query) *Token {
+	var tk *Token
+
+	for _, variant := range query.Variants {
+		if variant.Token != nil && (tk == nil || variant.Token.Start < tk.Start) {
+			tk = variant.Token
+		}
+	}
+
+	if tk == nil {
+		return dir.Token
+	}
+
+	return tk
+}
@@ -1,23 +1,117 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Code identifies the kind of problem reported by a SrcError, allowing tools to filter diagnostics.
+type Code string
+
+const (
+	CodeReadFailed          Code = "read-failed"
+	CodeSyntax              Code = "syntax"
+	CodeMissingKey          Code = "missing-key"
+	CodeInvalidValue        Code = "invalid-value"
+	CodeInvalidType         Code = "invalid-type"
+	CodeUnexpectedDirective Code = "unexpected-directive"
+	CodeUnknownDialect      Code = "unknown-dialect"
+	CodeDuplicate           Code = "duplicate"
+	CodeInvalidParam        Code = "invalid-param"
+	CodeInvalidQuery        Code = "invalid-query"
+	CodeUnusedArg           Code = "unused-arg"
+	CodePrepareFailed       Code = "prepare-failed"
+	CodeMismatch            Code = "mismatch"
+	CodeInferFailed         Code = "infer-failed"
+	CodeNoSchema            Code = "no-schema"
+	CodeUnknownFragment     Code = "unknown-fragment"
+	CodeImportFailed        Code = "import-failed"
+	CodeImportCycle         Code = "import-cycle"
+)
+
+// Span is a range of bytes within a single source line. Columns are counted from 1, with End being exclusive.
+type Span struct {
+	Line  int
+	Start int
+	End   int
+}
 
 type SrcError struct {
 	Token *Token
-	Inner error
+	// Span narrows the error to a section of the token, such as a single directive value. Nil when the error applies to
+	// the entire token.
+	Span     *Span
+	Severity Severity
+	Code     Code
+	Inner    error
 }
 
-func newSrcError(tk *Token, inner error) *SrcError {
+func newSrcError(tk *Token, code Code, inner error) *SrcError {
 	return &SrcError{
 		Token: tk,
+		Code:  code,
 		Inner: inner,
 	}
 }
 
-func wrapSrcError(tk *Token, format string, a ...any) *SrcError {
-	return newSrcError(tk, fmt.Errorf(format, a...)) //nolint:goerr113
+func wrapSrcError(tk *Token, code Code, format string, a ...any) *SrcError {
+	return newSrcError(tk, code, fmt.Errorf(format, a...)) //nolint:goerr113
 }
 
 func (e *SrcError) Error() string {
+	if e.Token == nil {
+		return e.Inner.Error()
+	}
+
 	return fmt.Sprintf("%v-%v: %v", e.Token.Start, e.Token.End, e.Inner)
 }
+
+func (e *SrcError) Unwrap() error {
+	return e.Inner
+}
+
+// at narrows the error to the given span, if known.
+func (e *SrcError) at(span *Span) *SrcError {
+	e.Span = span
+
+	return e
+}
+
+// Position returns the range covered by the error, with columns counted in bytes from 1 and the end column being
+// exclusive. Errors without a token apply to the start of the file.
+func (e *SrcError) Position() (line int, col int, endLine int, endCol int) {
+	if e.Span != nil {
+		return e.Span.Line, e.Span.Start, e.Span.Line, e.Span.End
+	}
+
+	tk := e.Token
+	if tk == nil {
+		return 1, 1, 1, 1
+	}
+
+	endCol = 1
+
+	if len(tk.RawLines) > 0 {
+		endCol = len(strings.TrimRight(tk.RawLines[len(tk.RawLines)-1], " \t")) + 1
+	}
+
+	return tk.Start, max(tk.Col, 1), tk.End, endCol
+}
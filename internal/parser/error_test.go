@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSrcErrorWithoutToken(t *testing.T) {
+	err := wrapSrcError(nil, CodeReadFailed, "failed to read")
+
+	if line, col, endLine, endCol := err.Position(); line != 1 || col != 1 || endLine != 1 || endCol != 1 {
+		t.Errorf("Position() = %v:%v-%v:%v, want 1:1-1:1", line, col, endLine, endCol)
+	}
+
+	if err.Error() != "failed to read" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "failed to read")
+	}
+}
+
+func TestParseLongLine(t *testing.T) {
+	src := "-- :cuttle version=1\n\n-- :repository name=UsersRepository\n-- :query name=Get mode=exec\n" +
+		"DELETE FROM users WHERE name = '" + strings.Repeat("a", 100*1024) + "';\n"
+
+	if _, err := parseString(t, "users.sql", src); err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	src += "-- :query name=Other mode=exec\nDELETE FROM users WHERE name = '" + strings.Repeat("a", maxLineLength) + "';\n"
+
+	_, err := parseString(t, "users.sql", src)
+
+	var srcErr *SrcError
+	if !errors.As(err, &srcErr) {
+		t.Fatalf("Parse() = %v, want SrcError", err)
+	}
+
+	if srcErr.Token == nil || srcErr.Token.Source != "users.sql" {
+		t.Fatalf("Token = %+v, want token in users.sql", srcErr.Token)
+	}
+
+	if line, _, _, _ := srcErr.Position(); line != 7 {
+		t.Errorf("Position() line = %v, want 7", line)
+	}
+}
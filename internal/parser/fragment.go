@@ -0,0 +1,279 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// fragmentRef matches an inline fragment reference, such as "{{user_columns}}".
+var fragmentRef = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Fragment is a reusable section of sql, referenced from queries using "{{name}}" or an include directive.
+type Fragment struct {
+	Token *Token
+	Name  string
+	// Variants contains the lines of the fragment for each dialect, or for the empty dialect when the fragment applies to
+	// every dialect.
+	Variants map[string][]string
+}
+
+// lines returns the lines of the fragment for the dialect.
+func (f *Fragment) lines(dialect string) ([]string, bool) {
+	if lines, ok := f.Variants[""]; ok {
+		return lines, true
+	}
+
+	lines, ok := f.Variants[dialect]
+
+	return lines, ok
+}
+
+// missing returns the fragment, either f or one of the fragments it references, that does not provide sql for the
+// dialect, or nil if the dialect is supported.
+func (f *Fragment) missing(fragments map[string]*Fragment, dialect string) *Fragment {
+	lines, ok := f.lines(dialect)
+	if !ok {
+		return f
+	}
+
+	for _, name := range fragmentRefs(lines) {
+		if m := fragments[name].missing(fragments, dialect); m != nil {
+			return m
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) parseFragment(dir *Directive) error {
+	name, ok := dir.Values["name"]
+	if !ok {
+		return dir.typeErrorf(CodeMissingKey, "%w: no name provided", ErrInvalidInput)
+	}
+
+	if !fragmentRef.MatchString("{{" + name + "}}") {
+		return dir.valueErrorf("name", CodeInvalidValue, "%w: invalid fragment name: %v", ErrInvalidInput, name)
+	}
+
+	if existing, ok := p.unit.Fragments[name]; ok {
+		return dir.valueErrorf(
+			"name",
+			CodeDuplicate,
+			"%w: fragment %v already defined at %v:%v",
+			ErrInvalidInput,
+			name,
+			existing.Token.Source,
+			existing.Token.Start,
+		)
+	}
+
+	p.logger.Debug("Parsing fragment", "name", name)
+
+	fragment := &Fragment{
+		Token:    dir.Token,
+		Name:     name,
+		Variants: make(map[string][]string),
+	}
+
+	dialects := []string{""}
+
+	for {
+		tk, err := p.next()
+		if errors.Is(err, io.EOF) {
+			return dir.errorf(CodeSyntax, "%w: unexpected eof inside fragment", ErrInvalidInput)
+		} else if err != nil {
+			return wrapSrcError(tk, CodeReadFailed, "failed to parse fragment token: %w", err)
+		}
+
+		if tk.Type == TokenTypeText {
+			// Dialect support of referenced fragments is checked where the fragment is used
+			if err := p.checkRefs(tk, nil); err != nil {
+				return err
+			}
+
+			for _, dialect := range dialects {
+				fragment.Variants[dialect] = append(fragment.Variants[dialect], tk.Content...)
+			}
+
+			continue
+		}
+
+		dir, err := tk.ParseDirective()
+		if err != nil {
+			return fmt.Errorf("failed to parse fragment directive: %w", err)
+		}
+
+		if dir.Type == DirectiveTypeEnd {
+			break
+		}
+
+		switch dir.Type {
+		case DirectiveTypeInclude:
+			ref, err := p.parseInclude(dir, nil)
+			if err != nil {
+				return err
+			}
+
+			for _, dialect := range dialects {
+				fragment.Variants[dialect] = append(fragment.Variants[dialect], ref)
+			}
+
+		case DirectiveTypeDialect:
+			rawDialect, ok := dir.Values["name"]
+			if !ok {
+				return dir.typeErrorf(CodeMissingKey, "%w: no name provided", ErrInvalidInput)
+			}
+
+			dialects = strings.Split(rawDialect, ",")
+			slices.Sort(dialects)
+			dialects = slices.Compact(dialects)
+
+			for _, dialect := range dialects {
+				if _, ok := fragment.Variants[dialect]; ok {
+					return dir.valueErrorf("name", CodeDuplicate, "%w: dialect already seen: %v", ErrInvalidInput, dialect)
+				}
+			}
+
+		default:
+			return dir.typeErrorf(CodeUnexpectedDirective, "%w: unexpected fragment directive: %v", ErrInvalidInput, dir.Type)
+		}
+	}
+
+	for dialect, lines := range fragment.Variants {
+		if fragmentSQL(lines) == "" {
+			delete(fragment.Variants, dialect)
+		}
+	}
+
+	if _, ok := fragment.Variants[""]; ok && len(fragment.Variants) != 1 {
+		return dir.errorf(CodeInvalidQuery, "%w: fragment contains sql outside of a dialect", ErrInvalidInput)
+	}
+
+	if len(fragment.Variants) == 0 {
+		return dir.errorf(CodeInvalidQuery, "%w: no sql found in fragment %v", ErrInvalidInput, name)
+	}
+
+	p.unit.Fragments[name] = fragment
+
+	return nil
+}
+
+// parseInclude validates an include directive, returning the equivalent inline fragment reference.
+func (p *parser) parseInclude(dir *Directive, dialects []string) (string, error) {
+	name, ok := dir.Values["name"]
+	if !ok {
+		return "", dir.typeErrorf(CodeMissingKey, "%w: no name provided", ErrInvalidInput)
+	}
+
+	if err := p.checkRef(dir.Token, dir.ValueSpans["name"], name, dialects); err != nil {
+		return "", err
+	}
+
+	return "{{" + name + "}}", nil
+}
+
+// checkRefs ensures the fragments referenced by a text token have been defined and support each of the dialects.
+func (p *parser) checkRefs(tk *Token, dialects []string) error {
+	for i, line := range tk.Content {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+
+		for _, match := range fragmentRef.FindAllStringSubmatchIndex(line, -1) {
+			span := &Span{
+				Line:  tk.Start + i,
+				Start: match[0] + 1,
+				End:   match[1] + 1,
+			}
+
+			if err := p.checkRef(tk, span, line[match[2]:match[3]], dialects); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) checkRef(tk *Token, span *Span, name string, dialects []string) error {
+	fragment, ok := p.unit.Fragments[name]
+	if !ok {
+		return wrapSrcError(tk, CodeUnknownFragment, "%w: unknown fragment %v", ErrInvalidInput, name).at(span)
+	}
+
+	for _, dialect := range dialects {
+		if m := fragment.missing(p.unit.Fragments, dialect); m != nil {
+			return wrapSrcError(
+				tk,
+				CodeUnknownDialect,
+				"%w: fragment %v has no sql for dialect %v",
+				ErrInvalidInput,
+				m.Name,
+				dialect,
+			).at(span)
+		}
+	}
+
+	return nil
+}
+
+// fragmentRefs returns the names of the fragments referenced by the lines.
+func fragmentRefs(lines []string) []string {
+	var names []string
+
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+
+		for _, match := range fragmentRef.FindAllStringSubmatch(line, -1) {
+			names = append(names, match[1])
+		}
+	}
+
+	return names
+}
+
+// expandFragments replaces the fragment references within the lines of a statement with the sql of each fragment for
+// the dialect. References must have been checked beforehand.
+func expandFragments(lines []string, fragments map[string]*Fragment, dialect string) []string {
+	expanded := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			expanded = append(expanded, line)
+
+			continue
+		}
+
+		expanded = append(expanded, fragmentRef.ReplaceAllStringFunc(line, func(ref string) string {
+			name := fragmentRef.FindStringSubmatch(ref)[1]
+			fragmentLines, _ := fragments[name].lines(dialect)
+
+			return fragmentSQL(expandFragments(fragmentLines, fragments, dialect))
+		}))
+	}
+
+	return expanded
+}
+
+// fragmentSQL joins the lines of a fragment, excluding comments.
+func fragmentSQL(lines []string) string {
+	var sql []string
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+
+		sql = append(sql, line)
+	}
+
+	return strings.Join(sql, "\n")
+}
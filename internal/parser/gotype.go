@@ -0,0 +1,269 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+type GoTypeKind string
+
+const (
+	GoTypeKindNamed   GoTypeKind = "named"
+	GoTypeKindPointer GoTypeKind = "pointer"
+	GoTypeKindSlice   GoTypeKind = "slice"
+	GoTypeKindArray   GoTypeKind = "array"
+	GoTypeKindMap     GoTypeKind = "map"
+)
+
+// GoType is a parsed Go type expression, such as "*time.Time" or "map[string]github.com/google/uuid.UUID".
+type GoType struct {
+	Kind GoTypeKind
+	// Path is the import path of a named type, or empty for builtin and local types.
+	Path string
+	// Name is the name of a named type.
+	Name string
+	// Len is the length of an array type.
+	Len string
+	// Key is the key type of a map.
+	Key *GoType
+	// Elem is the element type of pointers, slices, arrays and maps.
+	Elem *GoType
+	// TypeArgs contains the type arguments of an instantiated generic named type.
+	TypeArgs []*GoType
+}
+
+func (t *GoType) String() string {
+	switch t.Kind {
+	case GoTypeKindNamed:
+		var sb strings.Builder
+
+		if t.Path != "" {
+			sb.WriteString(t.Path)
+			sb.WriteString(".")
+		}
+
+		sb.WriteString(t.Name)
+
+		if len(t.TypeArgs) > 0 {
+			sb.WriteString("[")
+
+			for i, arg := range t.TypeArgs {
+				if i > 0 {
+					sb.WriteString(",")
+				}
+
+				sb.WriteString(arg.String())
+			}
+
+			sb.WriteString("]")
+		}
+
+		return sb.String()
+	case GoTypeKindPointer:
+		return "*" + t.Elem.String()
+	case GoTypeKindSlice:
+		return "[]" + t.Elem.String()
+	case GoTypeKindArray:
+		return "[" + t.Len + "]" + t.Elem.String()
+	case GoTypeKindMap:
+		return "map[" + t.Key.String() + "]" + t.Elem.String()
+	default:
+		panic("unexpected " + t.Kind)
+	}
+}
+
+// Replace returns a copy of the type with local named types substituted using the given overrides.
+func (t *GoType) Replace(overrides map[string]*GoType) *GoType {
+	if t.Kind == GoTypeKindNamed && t.Path == "" && len(t.TypeArgs) == 0 {
+		if override, ok := overrides[t.Name]; ok {
+			return override
+		}
+	}
+
+	c := *t
+
+	if c.Key != nil {
+		c.Key = c.Key.Replace(overrides)
+	}
+
+	if c.Elem != nil {
+		c.Elem = c.Elem.Replace(overrides)
+	}
+
+	c.TypeArgs = nil
+
+	for _, arg := range t.TypeArgs {
+		c.TypeArgs = append(c.TypeArgs, arg.Replace(overrides))
+	}
+
+	return &c
+}
+
+// ParseGoType parses a Go type expression. Named types may be qualified with their full import path, for example
+// "github.com/google/uuid.UUID".
+func ParseGoType(raw string) (*GoType, error) {
+	p := &goTypeParser{
+		src: strings.TrimSpace(raw),
+	}
+
+	ty, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.src) {
+		return nil, fmt.Errorf("%w: unexpected %q in type %q", ErrInvalidInput, p.src[p.pos:], raw)
+	}
+
+	return ty, nil
+}
+
+type goTypeParser struct {
+	src string
+	pos int
+}
+
+func (p *goTypeParser) rest() string {
+	return p.src[p.pos:]
+}
+
+func (p *goTypeParser) consume(prefix string) bool {
+	if !strings.HasPrefix(p.rest(), prefix) {
+		return false
+	}
+
+	p.pos += len(prefix)
+
+	return true
+}
+
+func (p *goTypeParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *goTypeParser) parseType() (*GoType, error) {
+	p.skipSpace()
+
+	if p.pos >= len(p.src) {
+		return nil, fmt.Errorf("%w: missing type in %q", ErrInvalidInput, p.src)
+	}
+
+	switch {
+	case p.consume("*"):
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		return &GoType{Kind: GoTypeKindPointer, Elem: elem}, nil
+
+	case p.consume("[]"):
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		return &GoType{Kind: GoTypeKindSlice, Elem: elem}, nil
+
+	case p.consume("["):
+		end := strings.IndexByte(p.rest(), ']')
+		if end <= 0 {
+			return nil, fmt.Errorf("%w: invalid array length in %q", ErrInvalidInput, p.src)
+		}
+
+		length := p.rest()[:end]
+		p.pos += end + 1
+
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		return &GoType{Kind: GoTypeKindArray, Len: length, Elem: elem}, nil
+
+	case p.consume("map["):
+		key, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.consume("]") {
+			return nil, fmt.Errorf("%w: unterminated map key in %q", ErrInvalidInput, p.src)
+		}
+
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		return &GoType{Kind: GoTypeKindMap, Key: key, Elem: elem}, nil
+
+	case p.consume("interface{}"):
+		return &GoType{Kind: GoTypeKindNamed, Name: "interface{}"}, nil
+
+	case p.consume("struct{}"):
+		return &GoType{Kind: GoTypeKindNamed, Name: "struct{}"}, nil
+
+	default:
+		return p.parseNamed()
+	}
+}
+
+func (p *goTypeParser) parseNamed() (*GoType, error) {
+	end := strings.IndexAny(p.rest(), "[],")
+	if end == -1 {
+		end = len(p.rest())
+	}
+
+	qualified := strings.TrimSpace(p.rest()[:end])
+	p.pos += end
+
+	ty := &GoType{
+		Kind: GoTypeKindNamed,
+		Name: qualified,
+	}
+
+	// The package path ends at the last dot following the final slash, allowing for paths such as "gopkg.in/yaml.v3"
+	slash := strings.LastIndexByte(qualified, '/')
+
+	if dot := strings.LastIndexByte(qualified[slash+1:], '.'); dot != -1 {
+		ty.Path = qualified[:slash+1+dot]
+		ty.Name = qualified[slash+1+dot+1:]
+	} else if slash != -1 {
+		return nil, fmt.Errorf("%w: missing type name in %q", ErrInvalidInput, qualified)
+	}
+
+	if !token.IsIdentifier(ty.Name) {
+		return nil, fmt.Errorf("%w: invalid type name %q", ErrInvalidInput, qualified)
+	}
+
+	if strings.ContainsAny(ty.Path, " \t") {
+		return nil, fmt.Errorf("%w: invalid package path %q", ErrInvalidInput, ty.Path)
+	}
+
+	if !p.consume("[") {
+		return ty, nil
+	}
+
+	for {
+		arg, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		ty.TypeArgs = append(ty.TypeArgs, arg)
+
+		if p.consume("]") {
+			break
+		}
+
+		if !p.consume(",") {
+			return nil, fmt.Errorf("%w: unterminated type arguments in %q", ErrInvalidInput, p.src)
+		}
+	}
+
+	return ty, nil
+}
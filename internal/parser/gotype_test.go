@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseGoType(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		kind GoTypeKind
+		path string
+		name string
+	}{
+		{raw: "int64", want: "int64", kind: GoTypeKindNamed, name: "int64"},
+		{raw: " string ", want: "string", kind: GoTypeKindNamed, name: "string"},
+		{raw: "time.Time", want: "time.Time", kind: GoTypeKindNamed, path: "time", name: "Time"},
+		{
+			raw:  "github.com/google/uuid.UUID",
+			want: "github.com/google/uuid.UUID",
+			kind: GoTypeKindNamed,
+			path: "github.com/google/uuid",
+			name: "UUID",
+		},
+		{raw: "gopkg.in/yaml.v3.Node", want: "gopkg.in/yaml.v3.Node", kind: GoTypeKindNamed, path: "gopkg.in/yaml.v3", name: "Node"},
+		{raw: "*time.Time", want: "*time.Time", kind: GoTypeKindPointer},
+		{raw: "[]byte", want: "[]byte", kind: GoTypeKindSlice},
+		{raw: "[16]byte", want: "[16]byte", kind: GoTypeKindArray},
+		{raw: "map[string]int", want: "map[string]int", kind: GoTypeKindMap},
+		{
+			raw:  "map[string][]*github.com/google/uuid.UUID",
+			want: "map[string][]*github.com/google/uuid.UUID",
+			kind: GoTypeKindMap,
+		},
+		{raw: "interface{}", want: "interface{}", kind: GoTypeKindNamed, name: "interface{}"},
+		{raw: "Pair[int, string]", want: "Pair[int,string]", kind: GoTypeKindNamed, name: "Pair"},
+		{
+			raw:  "example.com/x.Set[example.com/y.ID]",
+			want: "example.com/x.Set[example.com/y.ID]",
+			kind: GoTypeKindNamed,
+			path: "example.com/x",
+			name: "Set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			ty, err := ParseGoType(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseGoType(%q) failed: %v", tt.raw, err)
+			}
+
+			if got := ty.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+
+			if ty.Kind != tt.kind {
+				t.Errorf("Kind = %v, want %v", ty.Kind, tt.kind)
+			}
+
+			if tt.kind == GoTypeKindNamed && (ty.Path != tt.path || ty.Name != tt.name) {
+				t.Errorf("Path, Name = %q, %q, want %q, %q", ty.Path, ty.Name, tt.path, tt.name)
+			}
+		})
+	}
+}
+
+func TestParseGoTypeInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"*",
+		"[]",
+		"map[string",
+		"[]byte]",
+		"github.com/google/uuid",
+		"github.com/google/uuid.",
+		"time.Time extra",
+		"Pair[int",
+		"Pair[int;string]",
+		"1abc",
+	}
+
+	for _, raw := range tests {
+		t.Run(raw, func(t *testing.T) {
+			ty, err := ParseGoType(raw)
+			if err == nil {
+				t.Fatalf("ParseGoType(%q) = %v, want error", raw, ty)
+			}
+
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("ParseGoType(%q) error = %v, want %v", raw, err, ErrInvalidInput)
+			}
+		})
+	}
+}
+
+func TestGoTypeReplace(t *testing.T) {
+	ty, err := ParseGoType("map[ID][]*Pair[ID]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := ParseGoType("github.com/google/uuid.UUID")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := ty.Replace(map[string]*GoType{"ID": id}).String()
+	want := "map[github.com/google/uuid.UUID][]*Pair[github.com/google/uuid.UUID]"
+
+	if got != want {
+		t.Errorf("Replace() = %q, want %q", got, want)
+	}
+
+	if ty.String() != "map[ID][]*Pair[ID]" {
+		t.Errorf("Replace() modified the original type: %q", ty.String())
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// parseImport parses the file referenced by an import directive into the unit, making its fragments and repositories
+// available. Paths are relative to the importing file, and each file is only imported once.
+func (p *parser) parseImport(dir *Directive) error {
+	rawFile, ok := dir.Values["file"]
+	if !ok {
+		return dir.typeErrorf(CodeMissingKey, "%w: no file provided", ErrInvalidInput)
+	}
+
+	path := rawFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(p.tz.file), path)
+	}
+
+	path = filepath.Clean(path)
+	key := importKey(path)
+
+	if idx := slices.IndexFunc(p.stack, func(file string) bool {
+		return importKey(file) == key
+	}); idx != -1 {
+		cycle := append(slices.Clone(p.stack[idx:]), path)
+
+		return dir.valueErrorf("file", CodeImportCycle, "%w: import cycle: %v", ErrInvalidInput, strings.Join(cycle, " -> "))
+	}
+
+	if p.imported[key] {
+		return nil
+	}
+
+	p.imported[key] = true
+	p.unit.Imports = append(p.unit.Imports, path)
+
+	p.logger.Debug("Parsing import", "file", path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return dir.valueErrorf("file", CodeImportFailed, "failed to open import: %w", err)
+	}
+	defer f.Close()
+
+	child := &parser{
+		tz:       NewTokenizer(f, path),
+		logger:   p.logger,
+		unit:     p.unit,
+		imported: p.imported,
+		stack:    append(slices.Clone(p.stack), path),
+	}
+
+	err = child.Parse()
+	if errors.Is(err, io.EOF) {
+		return dir.valueErrorf("file", CodeImportFailed, "%w: %v is not a cuttle file", ErrInvalidInput, rawFile)
+	}
+
+	// Errors within the imported file are reported against that file, rather than the import directive
+	if err != nil {
+		p.errs = append(p.errs, err)
+	}
+
+	return nil
+}
+
+// importKey identifies a file regardless of how its path was written.
+func importKey(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+
+	return abs
+}
@@ -6,19 +6,117 @@ import (
 	"io"
 	"log/slog"
 	"maps"
+	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 )
 
-var ErrDocAlreadyExists = errors.New("doc comment already exists")
+var (
+	ErrDocAlreadyExists = errors.New("doc comment already exists")
+	ErrUnusedArg        = errors.New("unused arg")
+)
 
 type Unit struct {
 	Repositories      map[string]*Repository
 	RepositoriesOrder []string
+	// Fragments contains the fragments defined by the file and its imports, which have already been expanded within
+	// queries.
+	Fragments map[string]*Fragment
+	// Warnings contains problems that do not prevent code generation, such as unused args.
+	Warnings []*SrcError
+	// Imports contains the paths of the files imported by the file and its imports, including imports that failed to
+	// parse.
+	Imports []string
+}
+
+// Merge adds the repositories of other to the unit. Queries of repositories defined in both units are combined.
+func (u *Unit) Merge(other *Unit) error {
+	for _, name := range other.RepositoriesOrder {
+		repo := other.Repositories[name]
+
+		existing, ok := u.Repositories[name]
+		if !ok {
+			u.Repositories[name] = repo
+			u.RepositoriesOrder = append(u.RepositoriesOrder, name)
+
+			continue
+		}
+
+		if !slices.Equal(existing.Dialects, repo.Dialects) {
+			return fmt.Errorf("%w: repository %v defined with different dialects", ErrInvalidInput, name)
+		}
+
+		for _, query := range repo.Queries {
+			// Files imported by several inputs provide the same queries
+			if slices.ContainsFunc(existing.Queries, func(q *Query) bool {
+				return q.Token.Source == query.Token.Source && q.Token.Start == query.Token.Start
+			}) {
+				continue
+			}
+
+			if err := checkQueryName(existing, query); err != nil {
+				return err
+			}
+
+			existing.Queries = append(existing.Queries, query)
+		}
+
+		if existing.Doc == nil {
+			existing.Doc = repo.Doc
+		}
+	}
+
+	for name, fragment := range other.Fragments {
+		if _, ok := u.Fragments[name]; !ok {
+			if u.Fragments == nil {
+				u.Fragments = make(map[string]*Fragment)
+			}
+
+			u.Fragments[name] = fragment
+		}
+	}
+
+	u.Warnings = append(u.Warnings, other.Warnings...)
+
+	for _, path := range other.Imports {
+		if !slices.Contains(u.Imports, path) {
+			u.Imports = append(u.Imports, path)
+		}
+	}
+
+	return nil
+}
+
+// checkQueryName ensures a query does not share its name with an existing query of the repository, as both would
+// generate the same method.
+func checkQueryName(repo *Repository, query *Query) *SrcError {
+	idx := slices.IndexFunc(repo.Queries, func(q *Query) bool {
+		return q.Name == query.Name
+	})
+	if idx == -1 {
+		return nil
+	}
+
+	first := repo.Queries[idx].Token
+
+	return wrapSrcError(
+		query.Token,
+		CodeDuplicate,
+		"%w: query %v of %v is already defined at %v:%v",
+		ErrInvalidInput,
+		query.Name,
+		repo.Name,
+		first.Source,
+		first.Start,
+	)
 }
 
 type Repository struct {
+	// Token is the first repository directive declaring the repository.
+	Token    *Token
 	Name     string
+	Doc      *Doc
 	Queries  []*Query
 	Dialects []string
 }
@@ -29,17 +127,33 @@ var (
 	ModeExec      Mode = "exec"
 	ModeQueryMany Mode = "queryMany"
 	ModeQueryRow  Mode = "queryRow"
+	// ModeExecMany and ModeExecRow are writes returning rows, such as INSERT ... RETURNING.
+	ModeExecMany Mode = "execMany"
+	ModeExecRow  Mode = "execRow"
+	// ModeCopy bulk inserts rows into a table, with a column per arg.
+	ModeCopy Mode = "copy"
 )
 
 var ModeValues = map[string]Mode{
 	string(ModeExec):      ModeExec,
 	string(ModeQueryMany): ModeQueryMany,
 	string(ModeQueryRow):  ModeQueryRow,
+	string(ModeExecMany):  ModeExecMany,
+	string(ModeExecRow):   ModeExecRow,
+	string(ModeCopy):      ModeCopy,
 }
 
 type Query struct {
-	Name     string
-	Mode     Mode
+	Token *Token
+	Name  string
+	Mode  Mode
+	// Infer indicates the columns should be inferred from the schema, with Cols acting as overrides until inferred.
+	Infer bool
+	// Write indicates the query requires a write transaction. Exec, execRow, execMany and copy queries always require
+	// one.
+	Write bool
+	// Table is the table inserted into by copy queries.
+	Table    string
 	Doc      *Doc
 	Args     []*Arg
 	Cols     []*Col
@@ -47,23 +161,74 @@ type Query struct {
 }
 
 type Variant struct {
+	// Token spans the sql of the variant.
+	Token   *Token
 	Name    string
 	Content []string
 	Stmt    string
+	// Parts contains the statement split around named parameters, or nil if the statement does not use any.
+	Parts []*StmtPart
+}
+
+// StmtPart is a section of a statement, either raw sql or a reference to a named argument.
+type StmtPart struct {
+	Text string
+	Arg  *Arg
+	// In marks a list arg used as "IN (:arg)", or as "NOT IN (:arg)" when Not is set. The surrounding sql is removed
+	// from the adjacent parts, allowing dialects with array support to use "= ANY($1)" or "<> ALL($1)" instead.
+	In  bool
+	Not bool
 }
 
 type Doc struct {
 	Lines []string
 }
 
+// Text returns the lines of the doc block with any leading comment markers removed, excluding leading and trailing
+// blank lines.
+func (d *Doc) Text() []string {
+	lines := make([]string, 0, len(d.Lines))
+
+	for _, line := range d.Lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "--")
+
+		// A single space following the marker separates it from the text, while further indentation is preserved
+		line = strings.TrimPrefix(line, " ")
+
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
 type Arg struct {
-	Name string
-	Type string
+	Token    *Token
+	Name     string
+	Type     string
+	GoType   *GoType
+	Nullable bool
+	JSON     bool
+	List     bool
+	// Column is the column of the table a copy query inserts the arg into. It defaults to the name in snake case.
+	Column string
 }
 
 type Col struct {
-	Name string
-	Type string
+	Token    *Token
+	Name     string
+	Type     string
+	GoType   *GoType
+	Nullable bool
+	JSON     bool
 }
 
 type parser struct {
@@ -71,8 +236,16 @@ type parser struct {
 	logger *slog.Logger
 	queued *Token
 	unit   *Unit
+	errs   []error
+	// imported contains the files parsed into the unit, keyed by absolute path
+	imported map[string]bool
+	// stack contains the chain of files importing the current file, used to detect import cycles
+	stack []string
 }
 
+// Parse parses a cuttle source file. After an error, parsing resumes at the next query or repository so that every
+// error is reported, with all errors returned joined together. The unit is returned even when parsing fails so that
+// warnings can still be reported, but must not be used for generation.
 func Parse(in io.Reader, file string, logger *slog.Logger) (*Unit, error) {
 	tz := NewTokenizer(in, file)
 
@@ -81,14 +254,17 @@ func Parse(in io.Reader, file string, logger *slog.Logger) (*Unit, error) {
 		logger: logger,
 		unit: &Unit{
 			Repositories: make(map[string]*Repository),
+			Fragments:    make(map[string]*Fragment),
 		},
+		imported: map[string]bool{
+			importKey(file): true,
+		},
+		stack: []string{filepath.Clean(file)},
 	}
 
-	if err := p.Parse(); err != nil {
-		return nil, err
-	}
+	err := p.Parse()
 
-	return p.unit, nil
+	return p.unit, err
 }
 
 func (p *parser) next() (*Token, error) {
@@ -99,7 +275,13 @@ func (p *parser) next() (*Token, error) {
 		return t, nil
 	}
 
-	return p.tz.Next()
+	tk, err := p.tz.Next()
+	if err != nil && !errors.Is(err, io.EOF) {
+		// Failures to read a line produce no token, so are reported at the unread line instead
+		return p.tz.lineToken(), err
+	}
+
+	return tk, err
 }
 
 func (p *parser) queue(t *Token) {
@@ -110,6 +292,33 @@ func (p *parser) queue(t *Token) {
 	p.queued = t
 }
 
+// fail records an error, to be returned once parsing completes.
+func (p *parser) fail(err error) {
+	p.errs = append(p.errs, err)
+}
+
+// skipTo discards tokens until the next directive of one of the given types, allowing parsing to resume after an error.
+func (p *parser) skipTo(types ...DirectiveType) error {
+	for {
+		tk, err := p.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return wrapSrcError(tk, CodeReadFailed, "failed to parse token: %w", err)
+		}
+
+		if tk.Type != TokenTypeDirective {
+			continue
+		}
+
+		if slices.ContainsFunc(types, tk.IsDirective) {
+			p.queue(tk)
+
+			return nil
+		}
+	}
+}
+
 func (p *parser) Parse() error {
 	// Find start
 	var meta *Directive
@@ -123,7 +332,7 @@ func (p *parser) Parse() error {
 		if tk.IsDirective(DirectiveTypeCuttle) {
 			meta, err = tk.ParseDirective()
 			if err != nil {
-				return wrapSrcError(tk, "failed to parse cuttle directive: %w", err)
+				return fmt.Errorf("failed to parse cuttle directive: %w", err)
 			}
 
 			break
@@ -137,7 +346,9 @@ func (p *parser) Parse() error {
 		if errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
-			return wrapSrcError(tk, "failed to parse token: %w", err)
+			p.fail(wrapSrcError(tk, CodeReadFailed, "failed to parse token: %w", err))
+
+			break
 		}
 
 		if tk.Type != TokenTypeDirective {
@@ -146,23 +357,45 @@ func (p *parser) Parse() error {
 
 		dir, err := tk.ParseDirective()
 		if err != nil {
-			return wrapSrcError(tk, "failed to parse top level directive: %w", err)
+			p.fail(fmt.Errorf("failed to parse top level directive: %w", err))
+
+			continue
 		}
 
 		if dir.Type == DirectiveTypeMigration {
 			if err := p.parseMigration(dir); err != nil {
-				return fmt.Errorf("failed to parse migration: %w", err)
+				p.fail(fmt.Errorf("failed to parse migration: %w", err))
+			}
+		} else if dir.Type == DirectiveTypeImport {
+			if err := p.parseImport(dir); err != nil {
+				p.fail(fmt.Errorf("failed to parse import: %w", err))
+			}
+		} else if dir.Type == DirectiveTypeFragment {
+			if err := p.parseFragment(dir); err != nil {
+				p.fail(fmt.Errorf("failed to parse fragment: %w", err))
+
+				if err := p.skipTo(DirectiveTypeRepository, DirectiveTypeMigration, DirectiveTypeFragment); err != nil {
+					p.fail(err)
+
+					break
+				}
 			}
 		} else if dir.Type == DirectiveTypeRepository {
 			if err := p.parseRepository(dir); err != nil {
-				return fmt.Errorf("failed to parse repository: %w", err)
+				p.fail(fmt.Errorf("failed to parse repository: %w", err))
+
+				if err := p.skipTo(DirectiveTypeRepository, DirectiveTypeMigration, DirectiveTypeFragment); err != nil {
+					p.fail(err)
+
+					break
+				}
 			}
 		} else {
-			return wrapSrcError(tk, "%w: unexpected top level directive: %v", ErrInvalidInput, dir.Type)
+			p.fail(dir.typeErrorf(CodeUnexpectedDirective, "%w: unexpected top level directive: %v", ErrInvalidInput, dir.Type))
 		}
 	}
 
-	return nil
+	return errors.Join(p.errs...)
 }
 
 func (p *parser) parseMigration(_ *Directive) error {
@@ -173,7 +406,7 @@ func (p *parser) parseMigration(_ *Directive) error {
 		if errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
-			return wrapSrcError(tk, "failed to parse migration token: %w", err)
+			return wrapSrcError(tk, CodeReadFailed, "failed to parse migration token: %w", err)
 		}
 
 		if tk.Type != TokenTypeDirective {
@@ -182,7 +415,7 @@ func (p *parser) parseMigration(_ *Directive) error {
 
 		dir, err := tk.ParseDirective()
 		if err != nil {
-			return wrapSrcError(tk, "failed to parse migration directive: %w", err)
+			return fmt.Errorf("failed to parse migration directive: %w", err)
 		}
 
 		if dir.Type == DirectiveTypeMigration || dir.Type == DirectiveTypeRepository {
@@ -198,7 +431,7 @@ func (p *parser) parseMigration(_ *Directive) error {
 func (p *parser) parseRepository(dir *Directive) error {
 	name, ok := dir.Values["name"]
 	if !ok {
-		return wrapSrcError(dir.Token, "%w: no name provided", ErrInvalidInput)
+		return dir.typeErrorf(CodeMissingKey, "%w: no name provided", ErrInvalidInput)
 	}
 
 	p.logger.Debug("Parsing repository", "name", name)
@@ -206,7 +439,8 @@ func (p *parser) parseRepository(dir *Directive) error {
 	repo, ok := p.unit.Repositories[name]
 	if !ok {
 		repo = &Repository{
-			Name: name,
+			Token: dir.Token,
+			Name:  name,
 		}
 
 		p.unit.Repositories[name] = repo
@@ -227,7 +461,7 @@ func (p *parser) parseRepository(dir *Directive) error {
 		if errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
-			return wrapSrcError(tk, "failed to parse repository token: %w", err)
+			return wrapSrcError(tk, CodeReadFailed, "failed to parse repository token: %w", err)
 		}
 
 		if tk.Type != TokenTypeDirective {
@@ -236,51 +470,117 @@ func (p *parser) parseRepository(dir *Directive) error {
 
 		dir, err := tk.ParseDirective()
 		if err != nil {
-			return wrapSrcError(tk, "failed to parse repository directive: %w", err)
-		}
-
-		if dir.Type == DirectiveTypeMigration || dir.Type == DirectiveTypeRepository {
+			err = fmt.Errorf("failed to parse repository directive: %w", err)
+		} else if dir.Type == DirectiveTypeMigration || dir.Type == DirectiveTypeRepository {
 			p.queue(tk)
 
 			break
-		}
+		} else if dir.Type == DirectiveTypeQuery {
+			var query *Query
 
-		if dir.Type == DirectiveTypeQuery {
-			query, err := p.parseQuery(dir, repo.Dialects)
+			query, err = p.parseQuery(dir, repo.Dialects)
 			if err != nil {
-				return fmt.Errorf("failed to parse query: %w", err)
+				err = fmt.Errorf("failed to parse query: %w", err)
+			} else if dupErr := checkQueryName(repo, query); dupErr != nil {
+				err = dupErr.at(dir.ValueSpans["name"])
+			} else {
+				repo.Queries = append(repo.Queries, query)
 			}
-
-			repo.Queries = append(repo.Queries, query)
+		} else if dir.Type == DirectiveTypeFragment {
+			err = p.parseFragment(dir)
+			if err != nil {
+				err = fmt.Errorf("failed to parse fragment: %w", err)
+			}
+		} else if dir.Type == DirectiveTypeDoc {
+			err = p.parseRepositoryDoc(repo, dir)
 		} else {
-			return wrapSrcError(tk, "%w: unexpected repository directive: %v", ErrInvalidInput, dir.Type)
+			err = dir.typeErrorf(CodeUnexpectedDirective, "%w: unexpected repository directive: %v", ErrInvalidInput, dir.Type)
+		}
+
+		if err != nil {
+			p.fail(err)
+
+			// Resume at the next query or fragment, or let the caller handle the next repository or migration
+			if err := p.skipTo(
+				DirectiveTypeQuery,
+				DirectiveTypeFragment,
+				DirectiveTypeRepository,
+				DirectiveTypeMigration,
+			); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// parseRepositoryDoc parses a doc block describing the repository, which must precede its first query.
+func (p *parser) parseRepositoryDoc(repo *Repository, dir *Directive) error {
+	d, err := p.parseDoc(dir)
+	if err != nil {
+		return fmt.Errorf("failed to parse doc: %w", err)
+	}
+
+	if repo.Doc != nil {
+		return newSrcError(dir.Token, CodeDuplicate, ErrDocAlreadyExists).at(dir.TypeSpan)
+	}
+
+	if len(repo.Queries) > 0 {
+		return dir.typeErrorf(CodeUnexpectedDirective, "%w: repository doc must precede its first query", ErrInvalidInput)
+	}
+
+	repo.Doc = d
+
+	return nil
+}
+
 func (p *parser) parseQuery(dir *Directive, repoDialects []string) (*Query, error) {
 	query := &Query{
+		Token:    dir.Token,
 		Variants: make(map[string]*Variant),
 	}
 	ok := false
 
 	query.Name, ok = dir.Values["name"]
 	if !ok {
-		return nil, wrapSrcError(dir.Token, "%w: no name provided", ErrInvalidInput)
+		return nil, dir.typeErrorf(CodeMissingKey, "%w: no name provided", ErrInvalidInput)
 	}
 
 	p.logger.Debug("Parsing query", "name", query.Name)
 
 	rawMode, ok := dir.Values["mode"]
 	if !ok {
-		return nil, wrapSrcError(dir.Token, "%w: no mode provided", ErrInvalidInput)
+		return nil, dir.typeErrorf(CodeMissingKey, "%w: no mode provided", ErrInvalidInput)
 	}
 
 	query.Mode, ok = ModeValues[rawMode]
 	if !ok {
-		return nil, wrapSrcError(dir.Token, "%w: invalid mode %v", ErrInvalidInput, rawMode)
+		return nil, dir.valueErrorf("mode", CodeInvalidValue, "%w: invalid mode %v", ErrInvalidInput, rawMode)
+	}
+
+	var err error
+
+	query.Infer, err = parseBool(dir, "infer")
+	if err != nil {
+		return nil, err
+	}
+
+	query.Write, err = parseBool(dir, "write")
+	if err != nil {
+		return nil, err
+	}
+
+	if table, ok := dir.Values["table"]; ok {
+		if query.Mode != ModeCopy {
+			return nil, dir.keyErrorf("table", CodeInvalidQuery, "%w: table is only supported by copy queries", ErrInvalidInput)
+		}
+
+		if !tableName.MatchString(table) {
+			return nil, dir.valueErrorf("table", CodeInvalidValue, "%w: invalid table name: %v", ErrInvalidInput, table)
+		}
+
+		query.Table = table
 	}
 
 	dialects := []string{""}
@@ -291,10 +591,14 @@ func (p *parser) parseQuery(dir *Directive, repoDialects []string) (*Query, erro
 		if errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
-			return nil, wrapSrcError(tk, "failed to parse query token: %w", err)
+			return nil, wrapSrcError(tk, CodeReadFailed, "failed to parse query token: %w", err)
 		}
 
 		if tk.Type == TokenTypeText {
+			if err := p.checkRefs(tk, targetDialects(dialects, repoDialects)); err != nil {
+				return nil, err
+			}
+
 			for _, dialect := range dialects {
 				variant, ok := query.Variants[dialect]
 				if !ok {
@@ -306,27 +610,48 @@ func (p *parser) parseQuery(dir *Directive, repoDialects []string) (*Query, erro
 				}
 
 				variant.Content = append(variant.Content, tk.Content...)
+				variant.Token = extendToken(variant.Token, tk)
 			}
 
 			continue
 		}
 
 		if tk.Type != TokenTypeDirective {
-			return nil, wrapSrcError(tk, "%w: unexpected query token: %v", ErrInvalidInput, tk.Type)
+			return nil, wrapSrcError(tk, CodeSyntax, "%w: unexpected query token: %v", ErrInvalidInput, tk.Type)
 		}
 
 		dir, err := tk.ParseDirective()
 		if err != nil {
-			return nil, wrapSrcError(tk, "failed to parse query directive: %w", err)
+			return nil, fmt.Errorf("failed to parse query directive: %w", err)
 		}
 
-		if dir.Type == DirectiveTypeMigration || dir.Type == DirectiveTypeRepository || dir.Type == DirectiveTypeQuery {
+		if dir.Type == DirectiveTypeMigration || dir.Type == DirectiveTypeRepository || dir.Type == DirectiveTypeQuery ||
+			dir.Type == DirectiveTypeFragment {
 			p.queue(tk)
 
 			break
 		}
 
 		switch dir.Type {
+		case DirectiveTypeInclude:
+			ref, err := p.parseInclude(dir, targetDialects(dialects, repoDialects))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse include: %w", err)
+			}
+
+			for _, dialect := range dialects {
+				variant, ok := query.Variants[dialect]
+				if !ok {
+					variant = &Variant{
+						Name: dialect,
+					}
+
+					query.Variants[dialect] = variant
+				}
+
+				variant.Content = append(variant.Content, ref)
+			}
+
 		case DirectiveTypeArg:
 			a, err := p.parseArg(dir)
 			if err != nil {
@@ -350,7 +675,7 @@ func (p *parser) parseQuery(dir *Directive, repoDialects []string) (*Query, erro
 			}
 
 			if query.Doc != nil {
-				return nil, newSrcError(tk, ErrDocAlreadyExists)
+				return nil, newSrcError(tk, CodeDuplicate, ErrDocAlreadyExists).at(dir.TypeSpan)
 			}
 
 			query.Doc = d
@@ -358,7 +683,7 @@ func (p *parser) parseQuery(dir *Directive, repoDialects []string) (*Query, erro
 		case DirectiveTypeDialect:
 			rawDialect, ok := dir.Values["name"]
 			if !ok {
-				return nil, wrapSrcError(dir.Token, "%w: no name provided", ErrInvalidInput)
+				return nil, dir.typeErrorf(CodeMissingKey, "%w: no name provided", ErrInvalidInput)
 			}
 
 			dialects = strings.Split(rawDialect, ",")
@@ -367,22 +692,53 @@ func (p *parser) parseQuery(dir *Directive, repoDialects []string) (*Query, erro
 
 			for _, dialect := range dialects {
 				if _, ok := seenDialects[dialect]; ok {
-					return nil, wrapSrcError(tk, "%w: dialect already seen: %v", ErrInvalidInput, dialect)
+					return nil, dir.valueErrorf("name", CodeDuplicate, "%w: dialect already seen: %v", ErrInvalidInput, dialect)
 				}
 
 				if !slices.Contains(repoDialects, dialect) {
-					return nil, wrapSrcError(tk, "%w: dialect not defined for repository: %v", ErrInvalidInput, dialect)
+					return nil, dir.valueErrorf(
+						"name",
+						CodeUnknownDialect,
+						"%w: dialect not defined for repository: %v",
+						ErrInvalidInput,
+						dialect,
+					)
 				}
 
 				seenDialects[dialect] = struct{}{}
 			}
 
 		default:
-			return nil, wrapSrcError(tk, "%w: unexpected query directive: %v", ErrInvalidInput, dir.Type)
+			return nil, dir.typeErrorf(CodeUnexpectedDirective, "%w: unexpected query directive: %v", ErrInvalidInput, dir.Type)
+		}
+	}
+
+	// Sql outside of a dialect applies to every dialect of the repository. It is copied to each dialect before fragments
+	// are expanded, as fragments may differ between dialects.
+	generic := false
+
+	if variant, ok := query.Variants[""]; ok && fragmentSQL(variant.Content) != "" {
+		for name, other := range query.Variants {
+			if name != "" && fragmentSQL(other.Content) != "" {
+				return nil, dir.errorf(CodeInvalidQuery, "%w: query contains sql outside of a dialect", ErrInvalidInput)
+			}
+		}
+
+		generic = true
+
+		clear(query.Variants)
+
+		for _, dialect := range repoDialects {
+			dv := *variant
+			dv.Name = dialect
+
+			query.Variants[dialect] = &dv
 		}
 	}
 
 	for _, variant := range query.Variants {
+		variant.Content = expandFragments(variant.Content, p.unit.Fragments, variant.Name)
+
 		for j, l := range variant.Content {
 			l = strings.TrimSpace(l)
 
@@ -406,85 +762,343 @@ func (p *parser) parseQuery(dir *Directive, repoDialects []string) (*Query, erro
 		return variant.Stmt == ""
 	})
 
-	if variant, ok := query.Variants[""]; ok {
-		if len(query.Variants) != 1 {
-			return nil, wrapSrcError(dir.Token, "%w: query contains sql outside of a dialect", ErrInvalidInput)
-		}
+	// The statements of copy queries are derived from the table and args
+	if query.Mode == ModeCopy && len(query.Variants) != 0 {
+		return nil, wrapSrcError(
+			sqlToken(dir, query),
+			CodeInvalidQuery,
+			"%w: copy queries can not contain sql",
+			ErrInvalidInput,
+		)
+	} else if query.Mode != ModeCopy && len(query.Variants) == 0 {
+		return nil, dir.errorf(CodeInvalidQuery, "%w: no sql found", ErrInvalidInput)
+	}
 
-		if len(repoDialects) > 1 {
-			return nil, wrapSrcError(dir.Token, "%w: unable to infer dialect as repository supports multiple", ErrInvalidInput)
+	positional := false
+
+	for _, variant := range query.Variants {
+		pos, err := parseParams(query, variant)
+		if err != nil {
+			return nil, newSrcError(dir.Token, CodeInvalidParam, err)
 		}
 
-		variant.Name = repoDialects[0]
-		query.Variants[variant.Name] = variant
+		if err := parseListParts(variant, generic); err != nil {
+			return nil, newSrcError(dir.Token, CodeInvalidParam, err)
+		}
 
-		delete(query.Variants, "")
+		positional = positional || pos
 	}
 
-	if len(query.Variants) == 0 {
-		return nil, wrapSrcError(dir.Token, "%w: no sql found", ErrInvalidInput)
+	// Statements using positional parameters are specific to a single dialect
+	if generic && len(repoDialects) > 1 && positional {
+		return nil, dir.errorf(
+			CodeInvalidParam,
+			"%w: unable to infer dialect as repository supports multiple, use named parameters instead",
+			ErrInvalidInput,
+		)
 	}
 
 	switch query.Mode {
-	case ModeQueryMany:
-	case ModeQueryRow:
-		if len(query.Cols) == 0 {
-			return nil, wrapSrcError(dir.Token, "%w: query contains no columns", ErrInvalidInput)
+	case ModeQueryMany, ModeQueryRow, ModeExecMany, ModeExecRow:
+		if len(query.Cols) == 0 && !query.Infer {
+			return nil, dir.errorf(CodeInvalidQuery, "%w: query contains no columns", ErrInvalidInput)
 		}
 	case ModeExec:
 		if len(query.Cols) != 0 {
-			return nil, wrapSrcError(dir.Token, "%w: exec queries can not contain columns", ErrInvalidInput)
+			return nil, dir.valueErrorf("mode", CodeInvalidQuery, "%w: exec queries can not contain columns", ErrInvalidInput)
 		}
+
+		if query.Infer {
+			return nil, dir.keyErrorf("infer", CodeInvalidQuery, "%w: exec queries can not infer columns", ErrInvalidInput)
+		}
+	case ModeCopy:
+		if err := checkCopy(dir, query); err != nil {
+			return nil, err
+		}
+
+		// Args are columns rather than parameters, so are never unused
+		return query, nil
 	default:
 		panic("unexpected")
 	}
 
+	for _, arg := range query.Args {
+		if arg.Column != "" {
+			return nil, wrapSrcError(
+				arg.Token,
+				CodeInvalidQuery,
+				"%w: column is only supported by copy queries",
+				ErrInvalidInput,
+			)
+		}
+	}
+
+	for _, arg := range unusedArgs(query) {
+		warning := wrapSrcError(arg.Token, CodeUnusedArg, "%w: %v is not used by %v", ErrUnusedArg, arg.Name, query.Name)
+		warning.Severity = SeverityWarning
+
+		p.unit.Warnings = append(p.unit.Warnings, warning)
+	}
+
 	return query, nil
 }
 
+// targetDialects returns the dialects that sql within a section of a query applies to, with sql outside of a dialect
+// applying to every dialect of the repository.
+func targetDialects(dialects []string, repoDialects []string) []string {
+	if len(dialects) == 1 && dialects[0] == "" {
+		return repoDialects
+	}
+
+	return dialects
+}
+
+// unusedArgs returns the args that are not referenced by any variant of the query.
+func unusedArgs(query *Query) []*Arg {
+	used := make(map[*Arg]bool)
+
+	for _, variant := range query.Variants {
+		if variant.Parts != nil {
+			for _, part := range variant.Parts {
+				if part.Arg != nil {
+					used[part.Arg] = true
+				}
+			}
+
+			continue
+		}
+
+		highest := 0
+
+		for _, param := range findParams(variant.Stmt) {
+			// Numbered parameters, such as "?2" or "$2", refer to a specific arg, while "?" follows the highest so far
+			idx := highest + 1
+
+			if n, err := strconv.Atoi(param.Name()); err == nil {
+				idx = n
+			}
+
+			highest = max(highest, idx)
+
+			if idx >= 1 && idx <= len(query.Args) {
+				used[query.Args[idx-1]] = true
+			}
+		}
+	}
+
+	var unused []*Arg
+
+	for _, arg := range query.Args {
+		if !used[arg] {
+			unused = append(unused, arg)
+		}
+	}
+
+	return unused
+}
+
+// parseParams resolves the named parameters used by a variant, reporting whether positional parameters are used.
+func parseParams(query *Query, variant *Variant) (bool, error) {
+	var (
+		named      bool
+		positional bool
+		parts      []*StmtPart
+	)
+
+	last := 0
+
+	for _, param := range findParams(variant.Stmt) {
+		if !param.Named() {
+			positional = true
+
+			continue
+		}
+
+		named = true
+
+		idx := slices.IndexFunc(query.Args, func(arg *Arg) bool {
+			return arg.Name == param.Name()
+		})
+		if idx == -1 {
+			return false, fmt.Errorf("%w: unknown parameter %v", ErrInvalidInput, param.Text)
+		}
+
+		parts = append(parts, &StmtPart{
+			Text: variant.Stmt[last:param.Start],
+		}, &StmtPart{
+			Text: param.Text,
+			Arg:  query.Args[idx],
+		})
+
+		last = param.End
+	}
+
+	if named && positional {
+		return false, fmt.Errorf("%w: named and positional parameters can not be mixed", ErrInvalidInput)
+	}
+
+	if named {
+		variant.Parts = append(parts, &StmtPart{
+			Text: variant.Stmt[last:],
+		})
+	}
+
+	return positional, nil
+}
+
 func (p *parser) parseArg(dir *Directive) (*Arg, error) {
-	arg := &Arg{}
+	arg := &Arg{
+		Token: dir.Token,
+	}
 	ok := false
 
 	arg.Name, ok = dir.Values["name"]
 	if !ok {
-		return nil, wrapSrcError(dir.Token, "%w: no name provided", ErrInvalidInput)
+		return nil, dir.typeErrorf(CodeMissingKey, "%w: no name provided", ErrInvalidInput)
 	}
 
 	arg.Type, ok = dir.Values["type"]
 	if !ok {
-		return nil, wrapSrcError(dir.Token, "%w: no type provided", ErrInvalidInput)
+		return nil, dir.typeErrorf(CodeMissingKey, "%w: no type provided", ErrInvalidInput)
+	}
+
+	var err error
+
+	arg.Type, arg.Nullable, err = parseNullable(dir, arg.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	arg.GoType, err = ParseGoType(arg.Type)
+	if err != nil {
+		return nil, newSrcError(dir.Token, CodeInvalidType, err).at(dir.ValueSpans["type"])
+	}
+
+	arg.JSON, err = parseJSON(dir, arg.Nullable)
+	if err != nil {
+		return nil, err
+	}
+
+	arg.List, err = parseBool(dir, "list")
+	if err != nil {
+		return nil, err
+	}
+
+	if column, ok := dir.Values["column"]; ok {
+		if column == "" {
+			return nil, dir.valueErrorf("column", CodeInvalidValue, "%w: empty column name", ErrInvalidInput)
+		}
+
+		arg.Column = column
+	}
+
+	if arg.List && arg.JSON {
+		return nil, dir.keyErrorf("list", CodeInvalidValue, "%w: list values can not be json", ErrInvalidInput)
 	}
 
 	return arg, nil
 }
 
 func (p *parser) parseCol(dir *Directive) (*Col, error) {
-	col := &Col{}
+	col := &Col{
+		Token: dir.Token,
+	}
 	ok := false
 
 	col.Name, ok = dir.Values["name"]
 	if !ok {
-		return nil, wrapSrcError(dir.Token, "%w: no name provided", ErrInvalidInput)
+		return nil, dir.typeErrorf(CodeMissingKey, "%w: no name provided", ErrInvalidInput)
 	}
 
 	col.Type, ok = dir.Values["type"]
 	if !ok {
-		return nil, wrapSrcError(dir.Token, "%w: no type provided", ErrInvalidInput)
+		return nil, dir.typeErrorf(CodeMissingKey, "%w: no type provided", ErrInvalidInput)
+	}
+
+	var err error
+
+	col.Type, col.Nullable, err = parseNullable(dir, col.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	col.GoType, err = ParseGoType(col.Type)
+	if err != nil {
+		return nil, newSrcError(dir.Token, CodeInvalidType, err).at(dir.ValueSpans["type"])
+	}
+
+	col.JSON, err = parseJSON(dir, col.Nullable)
+	if err != nil {
+		return nil, err
 	}
 
 	return col, nil
 }
 
+func parseNullable(dir *Directive, ty string) (string, bool, error) {
+	nullable, err := parseBool(dir, "nullable")
+	if err != nil {
+		return "", false, err
+	}
+
+	if strings.HasSuffix(ty, "?") {
+		ty = strings.TrimSuffix(ty, "?")
+		nullable = true
+	}
+
+	if ty == "" {
+		return "", false, dir.valueErrorf("type", CodeInvalidType, "%w: empty type provided", ErrInvalidInput)
+	}
+
+	return ty, nullable, nil
+}
+
+func parseJSON(dir *Directive, nullable bool) (bool, error) {
+	isJSON, err := parseBool(dir, "json")
+	if err != nil {
+		return false, err
+	}
+
+	if isJSON && nullable {
+		return false, dir.keyErrorf(
+			"json",
+			CodeInvalidValue,
+			"%w: json values can not be nullable, use a pointer type instead",
+			ErrInvalidInput,
+		)
+	}
+
+	return isJSON, nil
+}
+
+func parseBool(dir *Directive, key string) (bool, error) {
+	raw, ok := dir.Values[key]
+	if !ok {
+		return false, nil
+	}
+
+	// Bare keys, such as "json", enable the flag
+	if raw == "" {
+		return true, nil
+	}
+
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, dir.valueErrorf(key, CodeInvalidValue, "%w: invalid %v value: %v", ErrInvalidInput, key, raw)
+	}
+
+	return v, nil
+}
+
 func (p *parser) parseDoc(dir *Directive) (*Doc, error) {
 	doc := &Doc{}
 
 	for {
 		tk, err := p.next()
 		if errors.Is(err, io.EOF) {
-			return nil, wrapSrcError(dir.Token, "%w: unexpected eof inside doc block", ErrInvalidInput)
+			return nil, dir.errorf(CodeSyntax, "%w: unexpected eof inside doc block", ErrInvalidInput)
 		} else if err != nil {
-			return nil, wrapSrcError(tk, "failed to parse doc token: %w", err)
+			return nil, wrapSrcError(tk, CodeReadFailed, "failed to parse doc token: %w", err)
 		}
 
 		if tk.Type == TokenTypeText {
@@ -494,19 +1108,19 @@ func (p *parser) parseDoc(dir *Directive) (*Doc, error) {
 		}
 
 		if tk.Type != TokenTypeDirective {
-			return nil, wrapSrcError(tk, "%w: unexpected doc token: %v", ErrInvalidInput, tk.Type)
+			return nil, wrapSrcError(tk, CodeSyntax, "%w: unexpected doc token: %v", ErrInvalidInput, tk.Type)
 		}
 
 		dir, err := tk.ParseDirective()
 		if err != nil {
-			return nil, wrapSrcError(tk, "failed to parse doc directive: %w", err)
+			return nil, fmt.Errorf("failed to parse doc directive: %w", err)
 		}
 
 		if dir.Type == DirectiveTypeEnd {
 			break
 		}
 
-		return nil, wrapSrcError(tk, "%w: unexpected doc directive: %v", ErrInvalidInput, dir.Type)
+		return nil, dir.typeErrorf(CodeUnexpectedDirective, "%w: unexpected doc directive: %v", ErrInvalidInput, dir.Type)
 	}
 
 	return doc, nil
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func parseString(t *testing.T, file string, src string) (*Unit, error) {
+	t.Helper()
+
+	return Parse(strings.NewReader(src), file, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestDuplicateQueryName(t *testing.T) {
+	src := `-- :cuttle version=1
+
+-- :repository name=UsersRepository
+-- :query name=Get mode=exec
+DELETE FROM users;
+
+-- :query name=Get mode=exec
+DELETE FROM users;
+`
+
+	_, err := parseString(t, "users.sql", src)
+
+	var srcErr *SrcError
+	if !errors.As(err, &srcErr) {
+		t.Fatalf("Parse() = %v, want SrcError", err)
+	}
+
+	if srcErr.Code != CodeDuplicate {
+		t.Errorf("Code = %v, want %v", srcErr.Code, CodeDuplicate)
+	}
+
+	if line, col, _, _ := srcErr.Position(); line != 7 || col != 16 {
+		t.Errorf("Position() = %v:%v, want 7:16", line, col)
+	}
+}
+
+func TestMergeDuplicateQueryName(t *testing.T) {
+	src := `-- :cuttle version=1
+
+-- :repository name=UsersRepository
+-- :query name=Get mode=exec
+DELETE FROM users;
+`
+
+	first, err := parseString(t, "a.sql", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := parseString(t, "b.sql", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Merging a unit with itself, as happens when several inputs import the same file, is not a duplicate
+	if err := first.Merge(first); err != nil {
+		t.Fatalf("Merge() of the same queries failed: %v", err)
+	}
+
+	err = first.Merge(second)
+
+	var srcErr *SrcError
+	if !errors.As(err, &srcErr) {
+		t.Fatalf("Merge() = %v, want SrcError", err)
+	}
+
+	if srcErr.Token.Source != "b.sql" || srcErr.Code != CodeDuplicate {
+		t.Errorf("Merge() = %v in %v, want %v in b.sql", srcErr.Code, srcErr.Token.Source, CodeDuplicate)
+	}
+}
+
+func TestCopyColumns(t *testing.T) {
+	src := `-- :cuttle version=1
+
+-- :repository name=UsersRepository
+-- :query name=CopyUsers mode=copy table=users
+-- :arg name=userName type=string
+-- :arg name=role type=string column=user_role
+`
+
+	unit, err := parseString(t, "users.sql", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := unit.Repositories["UsersRepository"].Queries[0].Args
+
+	for i, want := range []string{"user_name", "user_role"} {
+		if args[i].Column != want {
+			t.Errorf("Column = %v, want %v", args[i].Column, want)
+		}
+	}
+}
+
+func TestInvalidCopy(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		code Code
+		line int
+	}{
+		{
+			name: "sql",
+			src:  "-- :query name=CopyUsers mode=copy table=users\n-- :arg name=id type=int64\nINSERT INTO users;\n",
+			code: CodeInvalidQuery,
+			line: 6,
+		},
+		{
+			name: "duplicate column",
+			src: "-- :query name=CopyUsers mode=copy table=users\n" +
+				"-- :arg name=a type=int64 column=id\n-- :arg name=id type=int64\n",
+			code: CodeDuplicate,
+			line: 6,
+		},
+		{
+			name: "column outside copy",
+			src:  "-- :query name=Delete mode=exec\n-- :arg name=id type=int64 column=id\nDELETE FROM users WHERE id = :id;\n",
+			code: CodeInvalidQuery,
+			line: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseString(t, "users.sql", "-- :cuttle version=1\n\n-- :repository name=UsersRepository\n"+tt.src)
+
+			var srcErr *SrcError
+			if !errors.As(err, &srcErr) {
+				t.Fatalf("Parse() = %v, want SrcError", err)
+			}
+
+			if srcErr.Code != tt.code {
+				t.Errorf("Code = %v, want %v", srcErr.Code, tt.code)
+			}
+
+			if line, _, _, _ := srcErr.Position(); line != tt.line {
+				t.Errorf("Position() line = %v, want %v", line, tt.line)
+			}
+		})
+	}
+}
+
+func TestRepositoryDoc(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		code Code
+		line int
+	}{
+		{
+			name: "before query",
+			src:  "-- :doc\n-- Users.\n-- :end\n\n-- :query name=Delete mode=exec\nDELETE FROM users;\n",
+		},
+		{
+			name: "after query",
+			src: "-- :query name=Delete mode=exec\nDELETE FROM users;\n\n-- :fragment name=cols\nid\n-- :end\n" +
+				"-- :doc\n-- Users.\n-- :end\n",
+			code: CodeUnexpectedDirective,
+			line: 10,
+		},
+		{
+			name: "duplicate",
+			src:  "-- :doc\n-- Users.\n-- :end\n-- :doc\n-- Again.\n-- :end\n",
+			code: CodeDuplicate,
+			line: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit, err := parseString(t, "users.sql", "-- :cuttle version=1\n\n-- :repository name=UsersRepository\n"+tt.src)
+
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("Parse() failed: %v", err)
+				}
+
+				if doc := unit.Repositories["UsersRepository"].Doc; doc == nil {
+					t.Errorf("Doc = nil, want doc")
+				}
+
+				return
+			}
+
+			var srcErr *SrcError
+			if !errors.As(err, &srcErr) {
+				t.Fatalf("Parse() = %v, want SrcError", err)
+			}
+
+			if srcErr.Code != tt.code {
+				t.Errorf("Code = %v, want %v", srcErr.Code, tt.code)
+			}
+
+			if line, _, _, _ := srcErr.Position(); line != tt.line {
+				t.Errorf("Position() line = %v, want %v", line, tt.line)
+			}
+		})
+	}
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"common.sql": "-- :cuttle version=1\n-- :import file=nested/shared.sql\n",
+		"nested/shared.sql": "-- :cuttle version=1\n-- :import file=../common.sql\n-- :import file=missing.sql\n" +
+			"-- :fragment name=cols\nid, name\n-- :end\n",
+	}
+
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := "-- :cuttle version=1\n-- :import file=common.sql\n-- :import file=nested/../common.sql\n"
+
+	unit, err := parseString(t, filepath.Join(dir, "users.sql"), src)
+	if err == nil {
+		t.Fatal("Parse() succeeded, want missing import error")
+	}
+
+	// Imports are recorded once, including those which failed to open
+	want := []string{
+		filepath.Join(dir, "common.sql"),
+		filepath.Join(dir, "nested", "shared.sql"),
+		filepath.Join(dir, "nested", "missing.sql"),
+	}
+
+	if !slices.Equal(unit.Imports, want) {
+		t.Errorf("Imports = %v, want %v", unit.Imports, want)
+	}
+}
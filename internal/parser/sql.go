@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/csnewman/cuttle/internal/sqlscan"
+)
+
+var (
+	listInPrefix = regexp.MustCompile(`(?i)\b(NOT\s+)?IN\s*\(\s*$`)
+	listInSuffix = regexp.MustCompile(`^\s*\)`)
+)
+
+type sqlParam struct {
+	Start int
+	End   int
+	Text  string
+}
+
+func (p sqlParam) Named() bool {
+	return p.Text[0] == ':' || p.Text[0] == '@'
+}
+
+func (p sqlParam) Name() string {
+	return p.Text[1:]
+}
+
+// findParams returns the parameters referenced by a statement, skipping over quoted strings, identifiers and comments.
+func findParams(stmt string) []sqlParam {
+	var params []sqlParam
+
+	for _, tk := range sqlscan.Scan(stmt) {
+		if tk.Kind != sqlscan.KindParam {
+			continue
+		}
+
+		params = append(params, sqlParam{
+			Start: tk.Start,
+			End:   tk.End,
+			Text:  tk.Text,
+		})
+	}
+
+	return params
+}
+
+// parseListParts marks the list args of a variant used as "IN (:arg)", as dialects binding lists as arrays require a
+// different syntax. Sql shared between dialects may only use list args in this form.
+func parseListParts(variant *Variant, generic bool) error {
+	// Parts alternate between sql and args, starting and ending with sql
+	for i := 1; i < len(variant.Parts); i += 2 {
+		part := variant.Parts[i]
+		if !part.Arg.List {
+			continue
+		}
+
+		before := variant.Parts[i-1]
+		after := variant.Parts[i+1]
+
+		prefix := listInPrefix.FindStringSubmatchIndex(before.Text)
+		suffix := listInSuffix.FindStringIndex(after.Text)
+
+		if prefix == nil || suffix == nil {
+			if generic {
+				return fmt.Errorf(
+					"%w: list parameter %v must be used as IN (%v) in sql shared between dialects",
+					ErrInvalidInput,
+					part.Text,
+					part.Text,
+				)
+			}
+
+			continue
+		}
+
+		part.In = true
+		part.Not = prefix[2] != -1
+		before.Text = before.Text[:prefix[0]]
+		after.Text = after.Text[suffix[1]:]
+	}
+
+	return nil
+}
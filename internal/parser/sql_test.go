@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFindParams(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want []string
+	}{
+		{stmt: "SELECT 1", want: nil},
+		{stmt: "SELECT * FROM t WHERE a = :a AND b = @b", want: []string{":a", "@b"}},
+		{stmt: "SELECT ?, ?2, $3", want: []string{"?", "?2", "$3"}},
+		{stmt: "SELECT ':a', \":b\", `:c` FROM t WHERE d = :d", want: []string{":d"}},
+		{stmt: "SELECT 1 -- :a\n, 2 /* :b */ , :c", want: []string{":c"}},
+		{stmt: "SELECT x::text, :y::int", want: []string{":y"}},
+		{stmt: "SELECT $$:a$$, $tag$ ? $tag$, $1", want: []string{"$1"}},
+		{stmt: "SELECT '10:30', :1", want: nil},
+		{stmt: "SELECT tags[1:n], tags[lo:hi] FROM t WHERE id = :id", want: []string{":id"}},
+		{stmt: "SELECT * FROM t WHERE doc @@to_tsquery(:q)", want: []string{":q"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			var got []string
+
+			for _, param := range findParams(tt.stmt) {
+				if param.Text != tt.stmt[param.Start:param.End] {
+					t.Errorf("param %q does not match its span %v-%v", param.Text, param.Start, param.End)
+				}
+
+				got = append(got, param.Text)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findParams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListParts(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		want []StmtPart
+	}{
+		{
+			name: "in",
+			stmt: "SELECT * FROM t WHERE id IN (:ids) AND x = :x",
+			want: []StmtPart{
+				{Text: "SELECT * FROM t WHERE id "},
+				{Text: ":ids", In: true},
+				{Text: " AND x = "},
+				{Text: ":x"},
+				{Text: ""},
+			},
+		},
+		{
+			name: "not in",
+			stmt: "SELECT * FROM t WHERE id not in( :ids )",
+			want: []StmtPart{
+				{Text: "SELECT * FROM t WHERE id "},
+				{Text: ":ids", In: true, Not: true},
+				{Text: ""},
+			},
+		},
+		{
+			name: "dialect specific",
+			stmt: "SELECT * FROM t WHERE id = ANY(:ids)",
+			want: []StmtPart{
+				{Text: "SELECT * FROM t WHERE id = ANY("},
+				{Text: ":ids"},
+				{Text: ")"},
+			},
+		},
+		{
+			name: "identifier ending in in",
+			stmt: "SELECT * FROM t WHERE join(:ids)",
+			want: []StmtPart{
+				{Text: "SELECT * FROM t WHERE join("},
+				{Text: ":ids"},
+				{Text: ")"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &Query{
+				Args: []*Arg{
+					{Name: "ids", List: true},
+					{Name: "x"},
+				},
+			}
+			variant := &Variant{Stmt: tt.stmt}
+
+			if _, err := parseParams(query, variant); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := parseListParts(variant, false); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]StmtPart, 0, len(variant.Parts))
+
+			for _, part := range variant.Parts {
+				got = append(got, StmtPart{Text: part.Text, In: part.In, Not: part.Not})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parts = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListPartsGeneric(t *testing.T) {
+	query := &Query{
+		Args: []*Arg{{Name: "ids", List: true}},
+	}
+	variant := &Variant{Stmt: "SELECT * FROM t WHERE id = ANY(:ids)"}
+
+	if _, err := parseParams(query, variant); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parseListParts(variant, true); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("parseListParts() = %v, want %v", err, ErrInvalidInput)
+	}
+}
@@ -3,8 +3,8 @@ package parser
 import (
 	"bufio"
 	"errors"
-	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"unicode"
 )
@@ -33,122 +33,347 @@ const (
 	DirectiveTypeDoc        = "doc"
 	DirectiveTypeCol        = "col"
 	DirectiveTypeDialect    = "dialect"
+	DirectiveTypeFragment   = "fragment"
+	DirectiveTypeInclude    = "include"
+	DirectiveTypeImport     = "import"
 )
 
+// DirectiveKeys lists the keys understood by each directive, for use by editor tooling.
+var DirectiveKeys = map[DirectiveType][]string{
+	DirectiveTypeCuttle:     {"version"},
+	DirectiveTypeEnd:        nil,
+	DirectiveTypeMigration:  nil,
+	DirectiveTypeApply:      nil,
+	DirectiveTypeStep:       nil,
+	DirectiveTypeRevert:     nil,
+	DirectiveTypeRepository: {"name", "dialects"},
+	DirectiveTypeQuery:      {"name", "mode", "infer", "write", "table"},
+	DirectiveTypeArg:        {"name", "type", "nullable", "json", "list", "column"},
+	DirectiveTypeDoc:        nil,
+	DirectiveTypeCol:        {"name", "type", "nullable", "json"},
+	DirectiveTypeDialect:    {"name"},
+	DirectiveTypeFragment:   {"name"},
+	DirectiveTypeInclude:    {"name"},
+	DirectiveTypeImport:     {"file"},
+}
+
 type Token struct {
-	Type     TokenType
-	Source   string
-	Start    int
-	End      int
+	Type   TokenType
+	Source string
+	Start  int
+	End    int
+	// Col is the column of the first byte of Content within the first raw line, counted from 1.
+	Col      int
 	Content  []string
 	RawLines []string
 }
 
 func (t *Token) IsDirective(name DirectiveType) bool {
-	if t.Type != TokenTypeDirective {
+	if t.Type != TokenTypeDirective || len(t.Content) == 0 {
 		return false
 	}
 
-	if len(t.Content) > 1 {
-		panic("more than 1 content line in directive")
+	key := t.Content[0]
+	if i := strings.IndexAny(key, " \t"); i != -1 {
+		key = key[:i]
 	}
 
-	key, _, _ := strings.Cut(t.Content[0], " ")
-
 	return strings.EqualFold(string(name), key)
 }
 
+// extendToken returns a token spanning both tokens when they are adjacent, otherwise the first token is returned.
+func extendToken(first *Token, next *Token) *Token {
+	if first == nil {
+		return next
+	}
+
+	if first.Source != next.Source || first.End+1 != next.Start {
+		return first
+	}
+
+	return &Token{
+		Type:     first.Type,
+		Source:   first.Source,
+		Start:    first.Start,
+		End:      next.End,
+		Col:      first.Col,
+		Content:  append(slices.Clone(first.Content), next.Content...),
+		RawLines: append(slices.Clone(first.RawLines), next.RawLines...),
+	}
+}
+
 type Directive struct {
 	Token  *Token
 	Type   DirectiveType
 	Values map[string]string
+	// TypeSpan, KeySpans and ValueSpans locate the parts of the directive within its line. Quoted value spans include
+	// the quotes.
+	TypeSpan   *Span
+	KeySpans   map[string]*Span
+	ValueSpans map[string]*Span
+}
+
+// errorf returns an error covering the entire directive.
+func (d *Directive) errorf(code Code, format string, a ...any) *SrcError {
+	return wrapSrcError(d.Token, code, format, a...)
+}
+
+// typeErrorf returns an error pointing at the directive type.
+func (d *Directive) typeErrorf(code Code, format string, a ...any) *SrcError {
+	return wrapSrcError(d.Token, code, format, a...).at(d.TypeSpan)
+}
+
+// keyErrorf returns an error pointing at the given key, or the directive type if the key is not present.
+func (d *Directive) keyErrorf(key string, code Code, format string, a ...any) *SrcError {
+	span, ok := d.KeySpans[key]
+	if !ok {
+		span = d.TypeSpan
+	}
+
+	return wrapSrcError(d.Token, code, format, a...).at(span)
 }
 
+// valueErrorf returns an error pointing at the value of the given key, or the directive type if the key is not present.
+func (d *Directive) valueErrorf(key string, code Code, format string, a ...any) *SrcError {
+	span, ok := d.ValueSpans[key]
+	if !ok {
+		span = d.TypeSpan
+	}
+
+	return wrapSrcError(d.Token, code, format, a...).at(span)
+}
+
+// ParseDirective parses the type and key=value pairs of a directive token. Values containing whitespace must be quoted,
+// with backslash escapes supported inside quotes for quotes (\"), backslashes (\\), newlines (\n) and tabs (\t). A key
+// without a value has an empty value.
 func (t *Token) ParseDirective() (*Directive, error) {
-	if t.Type != TokenTypeDirective {
-		panic("not directive")
+	if t.Type != TokenTypeDirective || len(t.Content) == 0 {
+		return nil, wrapSrcError(t, CodeSyntax, "%w: not a directive", ErrInvalidInput)
 	}
 
-	if len(t.Content) > 1 {
-		panic("more than 1 content line in directive")
+	src, span := t.joinContent()
+
+	last := t.Content[len(t.Content)-1]
+	if trailing := len(last) - len(strings.TrimRight(last, "\\")); trailing%2 == 1 {
+		return nil, wrapSrcError(t, CodeSyntax, "%w: line continuation must be followed by a comment line", ErrInvalidInput).
+			at(span(len(src)-1, len(src)))
 	}
 
-	rawTy, vals, _ := strings.Cut(t.Content[0], " ")
+	i := 0
+	for i < len(src) && !isSpace(src[i]) {
+		i++
+	}
 
-	ty := DirectiveType(strings.ToLower(rawTy))
+	dir := &Directive{
+		Token:      t,
+		Type:       DirectiveType(strings.ToLower(src[:i])),
+		Values:     make(map[string]string),
+		TypeSpan:   span(0, i),
+		KeySpans:   make(map[string]*Span),
+		ValueSpans: make(map[string]*Span),
+	}
 
-	vals = strings.TrimSpace(vals)
+	for i < len(src) {
+		if isSpace(src[i]) {
+			i++
 
-	parsedVals := make(map[string]string)
-	inQuotes := false
-	key := ""
+			continue
+		}
 
-	var sb strings.Builder
+		keyStart := i
+		for i < len(src) && !isSpace(src[i]) && src[i] != '=' && src[i] != '"' {
+			i++
+		}
+
+		key := src[keyStart:i]
 
-	for _, r := range vals {
-		if inQuotes {
-			if r == '"' {
-				parsedVals[key] = sb.String()
-				key = ""
-				inQuotes = false
+		switch {
+		case i < len(src) && src[i] == '"':
+			return nil, wrapSrcError(t, CodeSyntax, "%w: quotes only allowed in values", ErrInvalidInput).
+				at(span(i, i+1))
+		case key == "":
+			return nil, wrapSrcError(t, CodeSyntax, "%w: missing key before value", ErrInvalidInput).
+				at(span(i, i+1))
+		}
 
-				sb.Reset()
+		if _, ok := dir.Values[key]; ok {
+			return nil, wrapSrcError(t, CodeDuplicate, "%w: duplicate key %v", ErrInvalidInput, key).
+				at(span(keyStart, i))
+		}
 
-				continue
-			}
+		dir.KeySpans[key] = span(keyStart, i)
 
-			sb.WriteRune(r)
-		} else if unicode.IsSpace(r) {
-			if key != "" {
-				parsedVals[key] = sb.String()
-			}
+		// Keys without a value have an empty value
+		if i == len(src) || src[i] != '=' {
+			dir.Values[key] = ""
+			dir.ValueSpans[key] = span(i, i)
 
-			sb.Reset()
-			key = ""
-		} else if r == '=' {
-			key = sb.String()
-			sb.Reset()
-		} else if r == '"' {
-			if key == "" {
-				return nil, fmt.Errorf("%w: quotes only allowed in values", ErrInvalidInput)
+			continue
+		}
+
+		i++
+
+		value, end, err := parseValue(t, src, i, span)
+		if err != nil {
+			return nil, err
+		}
+
+		dir.Values[key] = value
+		dir.ValueSpans[key] = span(i, end)
+
+		i = end
+	}
+
+	return dir, nil
+}
+
+// joinContent joins the content lines of a multi-line directive with a space, returning a function mapping byte ranges
+// of the joined content back to spans within the source.
+func (t *Token) joinContent() (string, func(int, int) *Span) {
+	var (
+		sb    strings.Builder
+		lines []int
+		cols  []int
+	)
+
+	endCol := t.Col
+
+	for i, piece := range t.Content {
+		col := t.Col
+
+		if i > 0 {
+			// Separators are positioned at the end of the previous line
+			sb.WriteByte(' ')
+
+			lines = append(lines, t.Start+i-1)
+			cols = append(cols, endCol)
+
+			col = 1
+
+			if i < len(t.RawLines) {
+				end := len(strings.TrimRightFunc(t.RawLines[i], unicode.IsSpace))
+
+				// Continued lines end with a backslash that is excluded from the content
+				if i < len(t.Content)-1 {
+					end--
+				}
+
+				col = end - len(piece) + 1
 			}
+		}
+
+		sb.WriteString(piece)
 
-			inQuotes = true
-			sb.Reset()
-		} else {
-			sb.WriteRune(r)
+		for j := range len(piece) {
+			lines = append(lines, t.Start+i)
+			cols = append(cols, col+j)
 		}
+
+		endCol = col + len(piece)
 	}
 
-	if inQuotes {
-		return nil, fmt.Errorf("%w: unterminated quotes", ErrInvalidInput)
+	lines = append(lines, t.Start+len(t.Content)-1)
+	cols = append(cols, endCol)
+
+	// Spans are limited to a single line, so ranges crossing lines are truncated to the end of the first line
+	span := func(start int, end int) *Span {
+		line := lines[start]
+		endCol := cols[start]
+
+		for k := start; k < end && lines[k] == line; k++ {
+			endCol = cols[k] + 1
+		}
+
+		return &Span{
+			Line:  line,
+			Start: cols[start],
+			End:   endCol,
+		}
 	}
 
-	if sb.Len() > 0 && key == "" {
-		key = sb.String()
-		sb.Reset()
+	return sb.String(), span
+}
+
+// parseValue parses the value starting at the given offset, returning the value and the offset following it.
+func parseValue(t *Token, src string, start int, span func(int, int) *Span) (string, int, error) {
+	if start == len(src) || src[start] != '"' {
+		end := start
+		for end < len(src) && !isSpace(src[end]) {
+			if src[end] == '"' {
+				return "", 0, wrapSrcError(t, CodeSyntax, "%w: quotes only allowed around values", ErrInvalidInput).
+					at(span(end, end+1))
+			}
+
+			end++
+		}
+
+		return src[start:end], end, nil
 	}
 
-	if key != "" {
-		parsedVals[key] = sb.String()
+	var sb strings.Builder
+
+	for i := start + 1; i < len(src); i++ {
+		c := src[i]
+
+		if c == '"' {
+			if i+1 < len(src) && !isSpace(src[i+1]) {
+				return "", 0, wrapSrcError(t, CodeSyntax, "%w: expected whitespace after quoted value", ErrInvalidInput).
+					at(span(i+1, i+2))
+			}
+
+			return sb.String(), i + 1, nil
+		}
+
+		if c != '\\' {
+			sb.WriteByte(c)
+
+			continue
+		}
+
+		if i+1 == len(src) {
+			break
+		}
+
+		i++
+
+		switch src[i] {
+		case '"', '\\':
+			sb.WriteByte(src[i])
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		default:
+			return "", 0, wrapSrcError(t, CodeSyntax, "%w: invalid escape \\%c", ErrInvalidInput, src[i]).
+				at(span(i-1, i+1))
+		}
 	}
 
-	return &Directive{
-		Token:  t,
-		Type:   ty,
-		Values: parsedVals,
-	}, nil
+	return "", 0, wrapSrcError(t, CodeSyntax, "%w: unterminated quotes", ErrInvalidInput).
+		at(span(start, len(src)))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
 }
 
 type Tokenizer struct {
 	scanner *bufio.Scanner
 	queued  *Token
+	// pending is a line that has been read but not yet consumed
+	pending *string
 	file    string
 	line    int
+	// failed is set once a read failure has been returned, after which the tokenizer reports EOF
+	failed bool
 }
 
+// maxLineLength is the length of the longest line accepted by the tokenizer.
+const maxLineLength = 16 * 1024 * 1024
+
 func NewTokenizer(in io.Reader, file string) *Tokenizer {
 	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineLength)
 
 	return &Tokenizer{
 		scanner: scanner,
@@ -157,6 +382,40 @@ func NewTokenizer(in io.Reader, file string) *Tokenizer {
 	}
 }
 
+func (t *Tokenizer) nextLine() (string, bool) {
+	if t.pending != nil {
+		line := *t.pending
+		t.pending = nil
+		t.line++
+
+		return line, true
+	}
+
+	if !t.scanner.Scan() {
+		return "", false
+	}
+
+	t.line++
+
+	return t.scanner.Text(), true
+}
+
+// lineToken returns a token covering the next unread line, allowing failures to read the line to be reported.
+func (t *Tokenizer) lineToken() *Token {
+	return &Token{
+		Type:   TokenTypeText,
+		Source: t.file,
+		Start:  t.line + 1,
+		End:    t.line + 1,
+		Col:    1,
+	}
+}
+
+func (t *Tokenizer) unreadLine(line string) {
+	t.pending = &line
+	t.line--
+}
+
 func (t *Tokenizer) Next() (*Token, error) {
 	if t.queued != nil {
 		tk := t.queued
@@ -170,35 +429,22 @@ func (t *Tokenizer) Next() (*Token, error) {
 	textStart := -1
 	textEnd := -1
 
-	for t.scanner.Scan() {
-		t.line++
-
-		line := t.scanner.Text()
-		trimmed := strings.TrimSpace(line)
-
-		if strings.HasPrefix(trimmed, "--") {
-			cmd := strings.TrimSpace(strings.TrimPrefix(trimmed, "--"))
-
-			if strings.HasPrefix(cmd, ":") {
-				cmd = strings.TrimPrefix(cmd, ":")
-
-				tk := &Token{
-					Type:     TokenTypeDirective,
-					Source:   t.file,
-					Start:    t.line,
-					End:      t.line,
-					Content:  []string{cmd},
-					RawLines: []string{line},
-				}
+	for {
+		line, ok := t.nextLine()
+		if !ok {
+			break
+		}
 
-				if len(text) > 0 {
-					t.queued = tk
+		if cmd, ok := directiveContent(line); ok {
+			tk := t.readDirective(line, cmd)
 
-					break
-				}
+			if len(text) > 0 {
+				t.queued = tk
 
-				return tk, nil
+				break
 			}
+
+			return tk, nil
 		}
 
 		if textStart == -1 {
@@ -209,12 +455,14 @@ func (t *Tokenizer) Next() (*Token, error) {
 		text = append(text, line)
 	}
 
-	if len(text) == 0 {
-		return nil, io.EOF
+	if err := t.scanner.Err(); err != nil && !t.failed {
+		t.failed = true
+
+		return nil, err
 	}
 
-	if t.scanner.Err() != nil {
-		return nil, t.scanner.Err()
+	if len(text) == 0 {
+		return nil, io.EOF
 	}
 
 	return &Token{
@@ -222,7 +470,62 @@ func (t *Tokenizer) Next() (*Token, error) {
 		Source:   t.file,
 		Start:    textStart,
 		End:      textEnd,
+		Col:      1,
 		Content:  text,
 		RawLines: text,
 	}, nil
 }
+
+// readDirective reads a directive along with any continuation lines. A line ending in an unescaped backslash is
+// continued by the following line, which must be a comment. Each line of the directive is a separate content line,
+// with the continuation backslashes removed.
+func (t *Tokenizer) readDirective(line string, cmd string) *Token {
+	tk := &Token{
+		Type:     TokenTypeDirective,
+		Source:   t.file,
+		Start:    t.line,
+		End:      t.line,
+		Col:      len(strings.TrimRightFunc(line, unicode.IsSpace)) - len(cmd) + 1,
+		RawLines: []string{line},
+	}
+
+	for {
+		// An odd number of trailing backslashes ends with an unescaped backslash
+		trailing := len(cmd) - len(strings.TrimRight(cmd, "\\"))
+		if trailing%2 == 0 {
+			tk.Content = append(tk.Content, cmd)
+
+			return tk
+		}
+
+		next, ok := t.nextLine()
+		if !ok || !strings.HasPrefix(strings.TrimSpace(next), "--") {
+			if ok {
+				t.unreadLine(next)
+			}
+
+			// The backslash is kept so that the unterminated continuation is reported when parsed
+			tk.Content = append(tk.Content, cmd)
+
+			return tk
+		}
+
+		tk.Content = append(tk.Content, strings.TrimSuffix(cmd, "\\"))
+		tk.End = t.line
+		tk.RawLines = append(tk.RawLines, next)
+
+		cmd = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(next), "--"))
+	}
+}
+
+// directiveContent returns the content of a directive line, excluding the "-- :" prefix.
+func directiveContent(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+
+	cmd, ok := strings.CutPrefix(trimmed, "--")
+	if !ok {
+		return "", false
+	}
+
+	return strings.CutPrefix(strings.TrimSpace(cmd), ":")
+}
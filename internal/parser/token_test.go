@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseDirectiveString(t *testing.T, src string) (*Directive, error) {
+	t.Helper()
+
+	tk, err := NewTokenizer(strings.NewReader(src), "test.sql").Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tk.Type != TokenTypeDirective {
+		t.Fatalf("token type = %v, want directive", tk.Type)
+	}
+
+	return tk.ParseDirective()
+}
+
+func TestParseDirective(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		typ    DirectiveType
+		values map[string]string
+	}{
+		{
+			name:   "simple",
+			src:    "-- :query name=Get mode=exec",
+			typ:    DirectiveTypeQuery,
+			values: map[string]string{"name": "Get", "mode": "exec"},
+		},
+		{
+			name:   "type case",
+			src:    "-- :QUERY name=Get",
+			typ:    DirectiveTypeQuery,
+			values: map[string]string{"name": "Get"},
+		},
+		{
+			name:   "no values",
+			src:    "-- :end",
+			typ:    DirectiveTypeEnd,
+			values: map[string]string{},
+		},
+		{
+			name:   "bare keys",
+			src:    "-- :query name=Get infer write",
+			typ:    DirectiveTypeQuery,
+			values: map[string]string{"name": "Get", "infer": "", "write": ""},
+		},
+		{
+			name:   "extra whitespace",
+			src:    "--   :arg \t name=id   type=int64  ",
+			typ:    DirectiveTypeArg,
+			values: map[string]string{"name": "id", "type": "int64"},
+		},
+		{
+			name:   "equals in value",
+			src:    "-- :arg name=a type=b=c",
+			typ:    DirectiveTypeArg,
+			values: map[string]string{"name": "a", "type": "b=c"},
+		},
+		{
+			name:   "quoted",
+			src:    `-- :arg name="a b" type=""`,
+			typ:    DirectiveTypeArg,
+			values: map[string]string{"name": "a b", "type": ""},
+		},
+		{
+			name:   "escapes",
+			src:    `-- :arg name="q\"b\\n\n\t"`,
+			typ:    DirectiveTypeArg,
+			values: map[string]string{"name": "q\"b\\n\n\t"},
+		},
+		{
+			name:   "unquoted backslashes",
+			src:    `-- :arg name=a\b`,
+			typ:    DirectiveTypeArg,
+			values: map[string]string{"name": `a\b`},
+		},
+		{
+			name:   "even trailing backslashes",
+			src:    "-- :arg name=a\\\\\n-- :col name=b",
+			typ:    DirectiveTypeArg,
+			values: map[string]string{"name": `a\\`},
+		},
+		{
+			name:   "continuation",
+			src:    "-- :query name=Get \\\n--   mode=exec \\\n-- infer",
+			typ:    DirectiveTypeQuery,
+			values: map[string]string{"name": "Get", "mode": "exec", "infer": ""},
+		},
+		{
+			name:   "continuation within quotes",
+			src:    "-- :arg name=\"a\\\n-- b\"",
+			typ:    DirectiveTypeArg,
+			values: map[string]string{"name": "a b"},
+		},
+		{
+			name:   "odd trailing backslashes",
+			src:    "-- :arg name=a\\\\\\\n-- type=int64",
+			typ:    DirectiveTypeArg,
+			values: map[string]string{"name": `a\\`, "type": "int64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := parseDirectiveString(t, tt.src)
+			if err != nil {
+				t.Fatalf("ParseDirective() failed: %v", err)
+			}
+
+			if dir.Type != tt.typ {
+				t.Errorf("Type = %v, want %v", dir.Type, tt.typ)
+			}
+
+			if !reflect.DeepEqual(dir.Values, tt.values) {
+				t.Errorf("Values = %q, want %q", dir.Values, tt.values)
+			}
+		})
+	}
+}
+
+func TestParseDirectiveInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		code Code
+		span Span
+	}{
+		{
+			name: "duplicate key",
+			src:  "-- :arg name=a name=b",
+			code: CodeDuplicate,
+			span: Span{Line: 1, Start: 16, End: 20},
+		},
+		{
+			name: "duplicate bare key",
+			src:  "-- :query infer infer",
+			code: CodeDuplicate,
+			span: Span{Line: 1, Start: 17, End: 22},
+		},
+		{
+			name: "invalid escape",
+			src:  `-- :arg name="a\x"`,
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 16, End: 18},
+		},
+		{
+			name: "unterminated quotes",
+			src:  `-- :arg name="a`,
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 14, End: 16},
+		},
+		{
+			name: "escaped closing quote",
+			src:  `-- :arg name="a\"`,
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 14, End: 18},
+		},
+		{
+			name: "quoted key",
+			src:  `-- :arg "name"=a`,
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 9, End: 10},
+		},
+		{
+			name: "missing key",
+			src:  "-- :arg =a",
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 9, End: 10},
+		},
+		{
+			name: "quote within value",
+			src:  `-- :arg name=a"b"`,
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 15, End: 16},
+		},
+		{
+			name: "text after quoted value",
+			src:  `-- :arg name="a"b`,
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 17, End: 18},
+		},
+		{
+			name: "continuation at end of file",
+			src:  "-- :arg name=a \\",
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 16, End: 17},
+		},
+		{
+			name: "continuation before sql",
+			src:  "-- :arg name=a \\\nSELECT 1;",
+			code: CodeSyntax,
+			span: Span{Line: 1, Start: 16, End: 17},
+		},
+		{
+			name: "duplicate key on continued line",
+			src:  "-- :arg name=a \\\n--   name=b",
+			code: CodeDuplicate,
+			span: Span{Line: 2, Start: 6, End: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := parseDirectiveString(t, tt.src)
+
+			var srcErr *SrcError
+			if !errors.As(err, &srcErr) {
+				t.Fatalf("ParseDirective() = %+v, %v, want SrcError", dir, err)
+			}
+
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("ParseDirective() error = %v, want %v", err, ErrInvalidInput)
+			}
+
+			if srcErr.Code != tt.code {
+				t.Errorf("Code = %v, want %v", srcErr.Code, tt.code)
+			}
+
+			if srcErr.Span == nil || *srcErr.Span != tt.span {
+				t.Errorf("Span = %+v, want %+v", srcErr.Span, tt.span)
+			}
+		})
+	}
+}
+
+func TestDirectiveSpans(t *testing.T) {
+	src := "-- :query name=Get \\\n--   mode=exec \\\n--\tdesc=\"a b\""
+
+	dir, err := parseDirectiveString(t, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  *Span
+		want Span
+	}{
+		{name: "type", got: dir.TypeSpan, want: Span{Line: 1, Start: 5, End: 10}},
+		{name: "name key", got: dir.KeySpans["name"], want: Span{Line: 1, Start: 11, End: 15}},
+		{name: "name value", got: dir.ValueSpans["name"], want: Span{Line: 1, Start: 16, End: 19}},
+		{name: "mode key", got: dir.KeySpans["mode"], want: Span{Line: 2, Start: 6, End: 10}},
+		{name: "mode value", got: dir.ValueSpans["mode"], want: Span{Line: 2, Start: 11, End: 15}},
+		{name: "desc key", got: dir.KeySpans["desc"], want: Span{Line: 3, Start: 4, End: 8}},
+		{name: "desc value", got: dir.ValueSpans["desc"], want: Span{Line: 3, Start: 9, End: 14}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got == nil || *tt.got != tt.want {
+				t.Errorf("span = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package sqlscan
+
+import (
+	"strings"
+)
+
+type Kind int
+
+const (
+	// KindText is whitespace, punctuation and operators.
+	KindText Kind = iota
+	// KindWord is a keyword, unquoted identifier or number.
+	KindWord
+	// KindQuoted is a quoted string or identifier, including Postgres dollar quoted strings such as "$tag$text$tag$".
+	KindQuoted
+	// KindComment is a line comment, including its trailing newline, or a block comment.
+	KindComment
+	// KindParam is a parameter, such as "?", "?1", "$1", ":name", "@name" or "$name".
+	KindParam
+)
+
+// Token is a section of a statement. Tokens cover the entire statement, such that concatenating their text reproduces
+// it.
+type Token struct {
+	Kind  Kind
+	Start int
+	End   int
+	Text  string
+}
+
+// Scan splits a statement into tokens. Unterminated strings and comments extend to the end of the statement.
+func Scan(stmt string) []Token {
+	var tokens []Token
+
+	textStart := 0
+
+	emit := func(kind Kind, start int, end int) {
+		if textStart < start {
+			tokens = append(tokens, Token{Kind: KindText, Start: textStart, End: start, Text: stmt[textStart:start]})
+		}
+
+		tokens = append(tokens, Token{Kind: kind, Start: start, End: end, Text: stmt[start:end]})
+		textStart = end
+	}
+
+	i := 0
+	// brackets is the depth of square brackets, within which ":" may separate the bounds of a Postgres array slice
+	brackets := 0
+
+	for i < len(stmt) {
+		c := stmt[i]
+
+		switch {
+		case c == '[':
+			brackets++
+			i++
+
+		case c == ']':
+			brackets = max(brackets-1, 0)
+			i++
+
+		case c == ':' && brackets > 0 && i > 0 && isOperandEnd(stmt[i-1]):
+			// Array slices, such as "arr[1:n]"
+			i++
+
+		case strings.HasPrefix(stmt[i:], "@@"):
+			// Postgres operators, such as "vec @@to_tsquery('a')"
+			i += 2
+
+		case c == '\'' || c == '"' || c == '`':
+			end := skipUntil(stmt, i+1, string(c))
+			emit(KindQuoted, i, end)
+			i = end
+
+		case strings.HasPrefix(stmt[i:], "--"):
+			end := skipUntil(stmt, i, "\n")
+			emit(KindComment, i, end)
+			i = end
+
+		case strings.HasPrefix(stmt[i:], "/*"):
+			end := skipUntil(stmt, i+2, "*/")
+			emit(KindComment, i, end)
+			i = end
+
+		case strings.HasPrefix(stmt[i:], "::"):
+			// Postgres style casts, such as "::text"
+			i += 2
+
+		case c == '$' && i+1 < len(stmt) && !isDigit(stmt[i+1]):
+			end := i + 1
+
+			for end < len(stmt) && isIdentByte(stmt[end]) {
+				end++
+			}
+
+			if end < len(stmt) && stmt[end] == '$' {
+				end = skipUntil(stmt, end+1, stmt[i:end+1])
+				emit(KindQuoted, i, end)
+			} else if end > i+1 {
+				// SQLite parameters, such as "$name"
+				emit(KindParam, i, end)
+			}
+
+			i = end
+
+		case c == '?' || (c == '$' && i+1 < len(stmt)) ||
+			((c == ':' || c == '@') && i+1 < len(stmt) && isIdentByte(stmt[i+1]) && !isDigit(stmt[i+1])):
+			end := i + 1
+
+			for end < len(stmt) && isIdentByte(stmt[end]) {
+				end++
+			}
+
+			emit(KindParam, i, end)
+			i = end
+
+		case isIdentByte(c):
+			end := i + 1
+
+			for end < len(stmt) && isIdentByte(stmt[end]) {
+				end++
+			}
+
+			emit(KindWord, i, end)
+			i = end
+
+		default:
+			i++
+		}
+	}
+
+	if textStart < len(stmt) {
+		tokens = append(tokens, Token{Kind: KindText, Start: textStart, End: len(stmt), Text: stmt[textStart:]})
+	}
+
+	return tokens
+}
+
+func skipUntil(s string, from int, terminator string) int {
+	end := strings.Index(s[from:], terminator)
+	if end == -1 {
+		return len(s)
+	}
+
+	return from + end + len(terminator)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isOperandEnd reports whether c may end an operand, such as the lower bound of an array slice.
+func isOperandEnd(c byte) bool {
+	return isIdentByte(c) || c == ')' || c == ']'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,182 @@
+package sqlscan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want []Token
+	}{
+		{stmt: "", want: nil},
+		{
+			stmt: "SELECT id FROM t",
+			want: []Token{
+				{Kind: KindWord, Text: "SELECT"},
+				{Kind: KindText, Text: " "},
+				{Kind: KindWord, Text: "id"},
+				{Kind: KindText, Text: " "},
+				{Kind: KindWord, Text: "FROM"},
+				{Kind: KindText, Text: " "},
+				{Kind: KindWord, Text: "t"},
+			},
+		},
+		{
+			stmt: "a = ? + ?2 + $3 + :name + @other",
+			want: []Token{
+				{Kind: KindWord, Text: "a"},
+				{Kind: KindText, Text: " = "},
+				{Kind: KindParam, Text: "?"},
+				{Kind: KindText, Text: " + "},
+				{Kind: KindParam, Text: "?2"},
+				{Kind: KindText, Text: " + "},
+				{Kind: KindParam, Text: "$3"},
+				{Kind: KindText, Text: " + "},
+				{Kind: KindParam, Text: ":name"},
+				{Kind: KindText, Text: " + "},
+				{Kind: KindParam, Text: "@other"},
+			},
+		},
+		{
+			stmt: `'a?' "b:c" ` + "`d$1`",
+			want: []Token{
+				{Kind: KindQuoted, Text: "'a?'"},
+				{Kind: KindText, Text: " "},
+				{Kind: KindQuoted, Text: `"b:c"`},
+				{Kind: KindText, Text: " "},
+				{Kind: KindQuoted, Text: "`d$1`"},
+			},
+		},
+		{
+			stmt: "'it''s'",
+			want: []Token{
+				{Kind: KindQuoted, Text: "'it'"},
+				{Kind: KindQuoted, Text: "'s'"},
+			},
+		},
+		{
+			stmt: "-- :a ?\n/* $1 */x",
+			want: []Token{
+				{Kind: KindComment, Text: "-- :a ?\n"},
+				{Kind: KindComment, Text: "/* $1 */"},
+				{Kind: KindWord, Text: "x"},
+			},
+		},
+		{
+			stmt: "x::text",
+			want: []Token{
+				{Kind: KindWord, Text: "x"},
+				{Kind: KindText, Text: "::"},
+				{Kind: KindWord, Text: "text"},
+			},
+		},
+		{
+			stmt: "$$a ? b$$ $tag$:c$tag$",
+			want: []Token{
+				{Kind: KindQuoted, Text: "$$a ? b$$"},
+				{Kind: KindText, Text: " "},
+				{Kind: KindQuoted, Text: "$tag$:c$tag$"},
+			},
+		},
+		{
+			stmt: ":1 @ $",
+			want: []Token{
+				{Kind: KindText, Text: ":"},
+				{Kind: KindWord, Text: "1"},
+				{Kind: KindText, Text: " @ $"},
+			},
+		},
+		{
+			stmt: "a = $name + $n1",
+			want: []Token{
+				{Kind: KindWord, Text: "a"},
+				{Kind: KindText, Text: " = "},
+				{Kind: KindParam, Text: "$name"},
+				{Kind: KindText, Text: " + "},
+				{Kind: KindParam, Text: "$n1"},
+			},
+		},
+		{
+			stmt: "arr[1:n] + arr[f(x):n] + arr[:lo]",
+			want: []Token{
+				{Kind: KindWord, Text: "arr"},
+				{Kind: KindText, Text: "["},
+				{Kind: KindWord, Text: "1"},
+				{Kind: KindText, Text: ":"},
+				{Kind: KindWord, Text: "n"},
+				{Kind: KindText, Text: "] + "},
+				{Kind: KindWord, Text: "arr"},
+				{Kind: KindText, Text: "["},
+				{Kind: KindWord, Text: "f"},
+				{Kind: KindText, Text: "("},
+				{Kind: KindWord, Text: "x"},
+				{Kind: KindText, Text: "):"},
+				{Kind: KindWord, Text: "n"},
+				{Kind: KindText, Text: "] + "},
+				{Kind: KindWord, Text: "arr"},
+				{Kind: KindText, Text: "["},
+				{Kind: KindParam, Text: ":lo"},
+				{Kind: KindText, Text: "]"},
+			},
+		},
+		{
+			stmt: "v @@to_tsquery(:q) AND a=:b",
+			want: []Token{
+				{Kind: KindWord, Text: "v"},
+				{Kind: KindText, Text: " @@"},
+				{Kind: KindWord, Text: "to_tsquery"},
+				{Kind: KindText, Text: "("},
+				{Kind: KindParam, Text: ":q"},
+				{Kind: KindText, Text: ") "},
+				{Kind: KindWord, Text: "AND"},
+				{Kind: KindText, Text: " "},
+				{Kind: KindWord, Text: "a"},
+				{Kind: KindText, Text: "="},
+				{Kind: KindParam, Text: ":b"},
+			},
+		},
+		{
+			stmt: "'open -- ?",
+			want: []Token{
+				{Kind: KindQuoted, Text: "'open -- ?"},
+			},
+		},
+		{
+			stmt: "/* open ?",
+			want: []Token{
+				{Kind: KindComment, Text: "/* open ?"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			tokens := Scan(tt.stmt)
+
+			var (
+				got []Token
+				sb  strings.Builder
+			)
+
+			for _, tk := range tokens {
+				if tk.Text != tt.stmt[tk.Start:tk.End] {
+					t.Errorf("token %q does not match its span %v-%v", tk.Text, tk.Start, tk.End)
+				}
+
+				sb.WriteString(tk.Text)
+				got = append(got, Token{Kind: tk.Kind, Text: tk.Text})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %+v, want %+v", got, tt.want)
+			}
+
+			if sb.String() != tt.stmt {
+				t.Errorf("tokens join to %q, want %q", sb.String(), tt.stmt)
+			}
+		})
+	}
+}
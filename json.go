@@ -0,0 +1,78 @@
+package cuttle
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON wraps a value that is stored as JSON, such as in a Postgres json/jsonb column or a SQLite TEXT column. It is
+// encoded using encoding/json, unless the driver provides its own JSON support, such as the codecs of pgx.
+type JSON[T any] struct {
+	V T
+}
+
+func NewJSON[T any](v T) JSON[T] {
+	return JSON[T]{
+		V: v,
+	}
+}
+
+func (j *JSON[T]) Scan(value any) error {
+	var zero T
+
+	j.V = zero
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), &j.V)
+	case []byte:
+		return json.Unmarshal(v, &j.V)
+	default:
+		return fmt.Errorf("%w: cannot scan %T into JSON", ErrUnsupportedType, value)
+	}
+}
+
+func (j JSON[T]) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// JSONValue returns the wrapped value, allowing drivers to encode it using their own JSON support.
+func (j JSON[T]) JSONValue() any {
+	return j.V
+}
+
+// JSONTarget resets the wrapped value, returning a pointer to it for drivers to decode into using their own JSON
+// support.
+func (j *JSON[T]) JSONTarget() any {
+	var zero T
+
+	j.V = zero
+
+	return &j.V
+}
+
+// JSONArg is implemented by JSON, allowing drivers to bind the wrapped value.
+type JSONArg interface {
+	JSONValue() any
+}
+
+// JSONDest is implemented by pointers to JSON, allowing drivers to scan into the wrapped value.
+type JSONDest interface {
+	JSONTarget() any
+}
+
+var (
+	_ sql.Scanner   = (*JSON[any])(nil)
+	_ driver.Valuer = JSON[any]{}
+	_ JSONArg       = JSON[any]{}
+	_ JSONDest      = (*JSON[any])(nil)
+)
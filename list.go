@@ -0,0 +1,140 @@
+package cuttle
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/csnewman/cuttle/internal/sqlscan"
+)
+
+var ErrUnsupportedPlaceholder = errors.New("unsupported placeholder")
+
+// List marks a slice argument. Dialects with array support receive the slice as a single parameter, such as in
+// "id = ANY($1)", while others expand the placeholder into a parameter per element, such as in "id IN (?1)".
+type List[T any] []T
+
+func NewList[T any](v []T) List[T] {
+	return List[T](v)
+}
+
+func (l List[T]) listValues() []any {
+	values := make([]any, len(l))
+
+	for i, v := range l {
+		values[i] = v
+	}
+
+	return values
+}
+
+func (l List[T]) listSlice() any {
+	return []T(l)
+}
+
+type listArg interface {
+	listValues() []any
+	listSlice() any
+}
+
+// ExpandArgs rewrites a statement and its arguments for the given dialect, expanding any List arguments. Dialects
+// without array support must use "?" or "?NNN" placeholders for statements containing List arguments, with others such
+// as "$name" rejected as their positions can not be determined.
+func ExpandArgs(dialect Dialect, stmt string, args []any) (string, []any, error) {
+	hasList := false
+
+	for _, arg := range args {
+		if _, ok := arg.(listArg); ok {
+			hasList = true
+
+			break
+		}
+	}
+
+	if !hasList {
+		return stmt, args, nil
+	}
+
+	if dialect.ArrayArgs {
+		expanded := make([]any, len(args))
+
+		for i, arg := range args {
+			if l, ok := arg.(listArg); ok {
+				expanded[i] = l.listSlice()
+			} else {
+				expanded[i] = arg
+			}
+		}
+
+		return stmt, expanded, nil
+	}
+
+	// Compute the position of each argument once lists have been flattened
+	starts := make([]int, len(args))
+	counts := make([]int, len(args))
+	next := 1
+
+	var expanded []any
+
+	for i, arg := range args {
+		starts[i] = next
+
+		if l, ok := arg.(listArg); ok {
+			values := l.listValues()
+			counts[i] = len(values)
+			expanded = append(expanded, values...)
+		} else {
+			counts[i] = 1
+			expanded = append(expanded, arg)
+		}
+
+		next += counts[i]
+	}
+
+	var sb strings.Builder
+
+	sb.Grow(len(stmt))
+
+	maxIndex := 0
+
+	for _, tk := range sqlscan.Scan(stmt) {
+		if tk.Kind != sqlscan.KindParam {
+			sb.WriteString(tk.Text)
+
+			continue
+		}
+
+		if tk.Text[0] != '?' {
+			return "", nil, fmt.Errorf("%w: %v", ErrUnsupportedPlaceholder, tk.Text)
+		}
+
+		index := maxIndex + 1
+
+		if len(tk.Text) > 1 {
+			var err error
+
+			index, err = strconv.Atoi(tk.Text[1:])
+			if err != nil {
+				return "", nil, fmt.Errorf("%w: %v", ErrUnsupportedPlaceholder, tk.Text)
+			}
+		}
+
+		maxIndex = max(maxIndex, index)
+
+		if index < 1 || index > len(args) {
+			return "", nil, fmt.Errorf("%w: %v references a missing argument", ErrUnsupportedPlaceholder, tk.Text)
+		}
+
+		for i := range counts[index-1] {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+
+			sb.WriteString("?")
+			sb.WriteString(strconv.Itoa(starts[index-1] + i))
+		}
+	}
+
+	return sb.String(), expanded, nil
+}
@@ -0,0 +1,132 @@
+package cuttle
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExpandArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		stmt     string
+		args     []any
+		wantStmt string
+		wantArgs []any
+	}{
+		{
+			name:     "no lists",
+			dialect:  DialectSQLite,
+			stmt:     "SELECT * FROM t WHERE id = $1",
+			args:     []any{1},
+			wantStmt: "SELECT * FROM t WHERE id = $1",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "array args",
+			dialect:  DialectPostgres,
+			stmt:     "SELECT * FROM t WHERE id = ANY($1) AND name = $2",
+			args:     []any{NewList([]int64{1, 2}), "a"},
+			wantStmt: "SELECT * FROM t WHERE id = ANY($1) AND name = $2",
+			wantArgs: []any{[]int64{1, 2}, "a"},
+		},
+		{
+			name:     "numbered",
+			dialect:  DialectSQLite,
+			stmt:     "SELECT * FROM t WHERE name = ?1 AND id IN (?2) AND kind = ?3",
+			args:     []any{"a", NewList([]int64{1, 2, 3}), "b"},
+			wantStmt: "SELECT * FROM t WHERE name = ?1 AND id IN (?2, ?3, ?4) AND kind = ?5",
+			wantArgs: []any{"a", int64(1), int64(2), int64(3), "b"},
+		},
+		{
+			name:     "anonymous",
+			dialect:  DialectSQLite,
+			stmt:     "SELECT * FROM t WHERE id IN (?) AND name = ?",
+			args:     []any{NewList([]int64{1, 2}), "a"},
+			wantStmt: "SELECT * FROM t WHERE id IN (?1, ?2) AND name = ?3",
+			wantArgs: []any{int64(1), int64(2), "a"},
+		},
+		{
+			name:     "anonymous after numbered",
+			dialect:  DialectSQLite,
+			stmt:     "SELECT ?2, ?",
+			args:     []any{"a", NewList([]int64{1, 2}), "b"},
+			wantStmt: "SELECT ?2, ?3, ?4",
+			wantArgs: []any{"a", int64(1), int64(2), "b"},
+		},
+		{
+			name:     "reused",
+			dialect:  DialectSQLite,
+			stmt:     "SELECT ?1 UNION SELECT ?1",
+			args:     []any{NewList([]string{"a", "b"})},
+			wantStmt: "SELECT ?1, ?2 UNION SELECT ?1, ?2",
+			wantArgs: []any{"a", "b"},
+		},
+		{
+			name:     "empty",
+			dialect:  DialectSQLite,
+			stmt:     "SELECT * FROM t WHERE id IN (?1)",
+			args:     []any{NewList([]int64{})},
+			wantStmt: "SELECT * FROM t WHERE id IN ()",
+			wantArgs: nil,
+		},
+		{
+			name:     "quoted and comments",
+			dialect:  DialectSQLite,
+			stmt:     "SELECT '?', \"?\", `?` -- ?\n/* ? */ FROM t WHERE id IN (?1)",
+			args:     []any{NewList([]int64{1, 2})},
+			wantStmt: "SELECT '?', \"?\", `?` -- ?\n/* ? */ FROM t WHERE id IN (?1, ?2)",
+			wantArgs: []any{int64(1), int64(2)},
+		},
+		{
+			name:     "cast",
+			dialect:  DialectSQLite,
+			stmt:     "SELECT x::text FROM t WHERE id IN (?1)",
+			args:     []any{NewList([]int64{1})},
+			wantStmt: "SELECT x::text FROM t WHERE id IN (?1)",
+			wantArgs: []any{int64(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, args, err := ExpandArgs(tt.dialect, tt.stmt, tt.args)
+			if err != nil {
+				t.Fatalf("ExpandArgs() failed: %v", err)
+			}
+
+			if stmt != tt.wantStmt {
+				t.Errorf("ExpandArgs() stmt = %q, want %q", stmt, tt.wantStmt)
+			}
+
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("ExpandArgs() args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestExpandArgsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		args []any
+	}{
+		{name: "dollar", stmt: "SELECT $1", args: []any{NewList([]int{1})}},
+		{name: "named", stmt: "SELECT :ids", args: []any{NewList([]int{1})}},
+		{name: "dollar named", stmt: "SELECT * FROM t WHERE a = $a AND id IN (?2)", args: []any{1, NewList([]int{1, 2})}},
+		{name: "missing", stmt: "SELECT ?2", args: []any{NewList([]int{1})}},
+		{name: "zero", stmt: "SELECT ?0", args: []any{NewList([]int{1})}},
+		{name: "too many", stmt: "SELECT ?, ?", args: []any{NewList([]int{1})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, _, err := ExpandArgs(DialectSQLite, tt.stmt, tt.args)
+			if !errors.Is(err, ErrUnsupportedPlaceholder) {
+				t.Errorf("ExpandArgs() = %q, %v, want %v", stmt, err, ErrUnsupportedPlaceholder)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package cuttle
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+// Null represents a value that may be NULL. It can be scanned into and bound by all drivers.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{
+		V:     v,
+		Valid: true,
+	}
+}
+
+func (n *Null[T]) Scan(value any) error {
+	var inner sql.Null[T]
+
+	if err := inner.Scan(value); err != nil {
+		return err
+	}
+
+	n.V = inner.V
+	n.Valid = inner.Valid
+
+	return nil
+}
+
+func (n Null[T]) Value() (driver.Value, error) {
+	return sql.Null[T]{
+		V:     n.V,
+		Valid: n.Valid,
+	}.Value()
+}
+
+// Ptr returns a pointer to the value, or nil if the value is NULL.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+
+	v := n.V
+
+	return &v
+}
+
+var (
+	_ sql.Scanner   = (*Null[any])(nil)
+	_ driver.Valuer = Null[any]{}
+)
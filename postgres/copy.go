@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/csnewman/cuttle"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (t *RTx) CopyTo(ctx context.Context, query string, w io.Writer) (int64, error) {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+
+	res, err := t.tx.Conn().PgConn().CopyTo(ctx, w, "COPY ("+query+") TO STDOUT")
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (t *WTx) CopyFrom(ctx context.Context, table string, columns []string, src cuttle.CopyFromSource) (int64, error) {
+	return t.tx.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, copyFromSource{src})
+}
+
+func (t *WTx) CopyFromFunc(
+	ctx context.Context,
+	handler cuttle.TxFunc[cuttle.Exec],
+	table string,
+	columns []string,
+	src cuttle.CopyFromSource,
+) error {
+	n, err := t.CopyFrom(ctx, table, columns, src)
+	if err != nil {
+		return err
+	}
+
+	return handler(ctx, &Exec{res: pgconn.NewCommandTag("COPY " + strconv.FormatInt(n, 10))})
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/csnewman/cuttle"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// jsonArg passes the value of a cuttle.JSON to the JSON codecs of pgx. The value is hidden behind json.Marshaler, as
+// the codecs treat strings and byte slices as raw JSON rather than as values to encode.
+type jsonArg struct {
+	v any
+}
+
+func (a jsonArg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.v)
+}
+
+// expandArgs expands the List arguments of a statement, replacing any cuttle.JSON arguments to be encoded by pgx.
+func expandArgs(stmt string, args []any) (string, []any, error) {
+	stmt, args, err := cuttle.ExpandArgs(cuttle.DialectPostgres, stmt, args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return stmt, encodeArgs(args), nil
+}
+
+func encodeArgs(args []any) []any {
+	var encoded []any
+
+	for i, arg := range args {
+		j, ok := arg.(cuttle.JSONArg)
+		if !ok {
+			continue
+		}
+
+		if encoded == nil {
+			encoded = append([]any(nil), args...)
+		}
+
+		encoded[i] = jsonArg{v: j.JSONValue()}
+	}
+
+	if encoded == nil {
+		return args
+	}
+
+	return encoded
+}
+
+// scanDests replaces any cuttle.JSON destinations with their wrapped value, to be decoded by the JSON codecs of pgx.
+// Values the codecs would not decode, such as strings, are left to be scanned by cuttle.JSON itself.
+func scanDests(dest []any) []any {
+	var decoded []any
+
+	for i, d := range dest {
+		j, ok := d.(cuttle.JSONDest)
+		if !ok {
+			continue
+		}
+
+		target := j.JSONTarget()
+
+		switch target.(type) {
+		case *string, *[]byte, sql.Scanner, pgtype.BytesScanner:
+			continue
+		}
+
+		if decoded == nil {
+			decoded = append([]any(nil), dest...)
+		}
+
+		decoded[i] = target
+	}
+
+	if decoded == nil {
+		return dest
+	}
+
+	return decoded
+}
+
+// copyFromSource encodes any cuttle.JSON values of the rows of a bulk insert.
+type copyFromSource struct {
+	cuttle.CopyFromSource
+}
+
+func (s copyFromSource) Values() ([]any, error) {
+	values, err := s.CopyFromSource.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeArgs(values), nil
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/csnewman/cuttle"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type testDoc struct {
+	Title string `json:"title"`
+}
+
+func TestEncodeJSONArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		arg    any
+		oid    uint32
+		format int16
+		want   string
+	}{
+		{name: "json struct", arg: cuttle.NewJSON(testDoc{Title: "a"}), oid: pgtype.JSONOID, want: `{"title":"a"}`},
+		{
+			name:   "jsonb binary",
+			arg:    cuttle.NewJSON(testDoc{Title: "a"}),
+			oid:    pgtype.JSONBOID,
+			format: pgtype.BinaryFormatCode,
+			want:   "\x01" + `{"title":"a"}`,
+		},
+		{name: "string is encoded", arg: cuttle.NewJSON("a"), oid: pgtype.JSONBOID, want: `"a"`},
+		{name: "bytes are encoded", arg: cuttle.NewJSON([]byte("a")), oid: pgtype.JSONBOID, want: `"YQ=="`},
+		{name: "other args", arg: "a", oid: pgtype.TextOID, want: "a"},
+	}
+
+	m := pgtype.NewMap()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := m.Encode(tt.oid, tt.format, encodeArgs([]any{tt.arg})[0], nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(buf) != tt.want {
+				t.Errorf("Encode() = %q, want %q", buf, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanJSONDests(t *testing.T) {
+	m := pgtype.NewMap()
+
+	var doc cuttle.JSON[testDoc]
+
+	src := []byte("\x01" + `{"title":"a"}`)
+	if err := m.Scan(pgtype.JSONBOID, pgtype.BinaryFormatCode, src, scanDests([]any{&doc})[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.V.Title != "a" {
+		t.Errorf("Scan() = %+v, want title a", doc.V)
+	}
+
+	var str cuttle.JSON[string]
+	if err := m.Scan(pgtype.JSONBOID, pgtype.TextFormatCode, []byte(`"a"`), scanDests([]any{&str})[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if str.V != "a" {
+		t.Errorf("Scan() = %q, want a", str.V)
+	}
+
+	ptr := cuttle.NewJSON(&testDoc{Title: "b"})
+	if err := m.Scan(pgtype.JSONBOID, pgtype.TextFormatCode, nil, scanDests([]any{&ptr})[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if ptr.V != nil {
+		t.Errorf("Scan() = %+v, want nil", ptr.V)
+	}
+}
@@ -33,6 +33,18 @@ func (d *DB) ExecFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Exec], s
 	})
 }
 
+func (d *DB) CopyFromFunc(
+	ctx context.Context,
+	handler cuttle.TxFunc[cuttle.Exec],
+	table string,
+	columns []string,
+	src cuttle.CopyFromSource,
+) error {
+	return d.WTx(ctx, func(ctx context.Context, tx cuttle.WTx) error {
+		return tx.CopyFromFunc(ctx, handler, table, columns, src)
+	})
+}
+
 func (d *DB) QueryFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Rows], stmt string, args ...any) error {
 	return d.RTx(ctx, func(ctx context.Context, tx cuttle.RTx) error {
 		res, err := tx.Query(ctx, stmt, args...)
@@ -128,7 +140,7 @@ func (r *Rows) Next(dest ...any) (bool, error) {
 		return false, r.Close()
 	}
 
-	if err := r.res.Scan(dest...); err != nil {
+	if err := r.res.Scan(scanDests(dest)...); err != nil {
 		_ = r.Close()
 
 		return false, err
@@ -142,7 +154,7 @@ type Row struct {
 }
 
 func (r *Row) Scan(dest ...any) error {
-	return r.res.Scan(dest...)
+	return r.res.Scan(scanDests(dest)...)
 }
 
 type Exec struct {
@@ -26,6 +26,11 @@ func (t *RTx) QueryFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Rows],
 }
 
 func (t *RTx) Query(ctx context.Context, stmt string, args ...any) (cuttle.Rows, error) {
+	stmt, args, err := expandArgs(stmt, args)
+	if err != nil {
+		return nil, err
+	}
+
 	res, err := t.tx.Query(ctx, stmt, args...)
 	if err != nil {
 		return nil, err
@@ -44,6 +49,11 @@ func (t *RTx) QueryRowFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Row
 }
 
 func (t *RTx) QueryRow(ctx context.Context, stmt string, args ...any) (cuttle.Row, error) {
+	stmt, args, err := expandArgs(stmt, args)
+	if err != nil {
+		return nil, err
+	}
+
 	res, err := t.tx.Query(ctx, stmt, args...)
 	if err != nil {
 		return nil, err
@@ -75,7 +85,12 @@ func (t *RTx) dispatchBatch(ctx context.Context, entries []*cuttle.BatchEntry) e
 	pb := &pgx.Batch{}
 
 	for _, entry := range entries {
-		pb.Queue(entry.Stmt, entry.Args...)
+		stmt, args, err := expandArgs(entry.Stmt, entry.Args)
+		if err != nil {
+			return err
+		}
+
+		pb.Queue(stmt, args...)
 	}
 
 	res := t.tx.SendBatch(ctx, pb)
@@ -141,6 +156,11 @@ func (t *WTx) ExecFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Exec],
 }
 
 func (t *WTx) Exec(ctx context.Context, stmt string, args ...any) (cuttle.Exec, error) {
+	stmt, args, err := expandArgs(stmt, args)
+	if err != nil {
+		return nil, err
+	}
+
 	res, err := t.tx.Exec(ctx, stmt, args...)
 	if err != nil {
 		return nil, err
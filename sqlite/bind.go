@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/csnewman/cuttle"
+	"github.com/tailscale/sqlite/sqliteh"
+)
+
+// timeFormat matches the format used by sqlitepool, trimmed to the shortest form when bound.
+const timeFormat = "2006-01-02 15:04:05.000-0700"
+
+// bindAll binds args to the parameters of the statement, following the conversions of sqlitepool. Its implementation
+// is unexported and only usable with the statements it caches itself, so it is mirrored here for stmtCache.
+func bindAll(db sqliteh.DB, stmt sqliteh.Stmt, args []any) error {
+	for i, arg := range args {
+		if err := bind(db, stmt, i+1, arg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bind(db sqliteh.DB, stmt sqliteh.Stmt, ordinal int, v any) error {
+	if found, err := bindBasic(db, stmt, ordinal, v); found {
+		return err
+	}
+
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return fmt.Errorf("bind %v: invalid driver value: %w", ordinal, err)
+		}
+
+		if found, err := bindBasic(db, stmt, ordinal, value); found {
+			return err
+		}
+
+		v = value
+	}
+
+	if marshaler, ok := v.(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return fmt.Errorf("bind %v: failed to marshal %T: %w", ordinal, v, err)
+		}
+
+		_, err = bindBasic(db, stmt, ordinal, text)
+
+		return err
+	}
+
+	// Named types with a basic underlying type, such as enums
+	val := reflect.ValueOf(v)
+
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			_, err := bindBasic(db, stmt, ordinal, nil)
+
+			return err
+		}
+
+		val = val.Elem()
+	}
+
+	var basic any
+
+	switch val.Kind() {
+	case reflect.Bool:
+		if val.Bool() {
+			basic = int64(1)
+		} else {
+			basic = int64(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		basic = val.Int()
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		basic = int64(val.Uint())
+	case reflect.Float32, reflect.Float64:
+		basic = val.Float()
+	case reflect.String:
+		basic = val.String()
+	default:
+		return fmt.Errorf("%w: bind %v: %T", cuttle.ErrUnsupportedType, ordinal, v)
+	}
+
+	_, err := bindBasic(db, stmt, ordinal, basic)
+
+	return err
+}
+
+func bindBasic(db sqliteh.DB, stmt sqliteh.Stmt, ordinal int, v any) (bool, error) {
+	var err error
+
+	switch v := v.(type) {
+	case nil:
+		err = stmt.BindNull(ordinal)
+	case string:
+		err = stmt.BindText64(ordinal, v)
+	case int:
+		err = stmt.BindInt64(ordinal, int64(v))
+	case int64:
+		err = stmt.BindInt64(ordinal, v)
+	case float64:
+		err = stmt.BindDouble(ordinal, v)
+	case bool:
+		if v {
+			err = stmt.BindInt64(ordinal, 1)
+		} else {
+			err = stmt.BindInt64(ordinal, 0)
+		}
+	case []byte:
+		if len(v) == 0 {
+			err = stmt.BindZeroBlob64(ordinal, 0)
+		} else {
+			err = stmt.BindBlob64(ordinal, v)
+		}
+	case time.Time:
+		text := v.Format(timeFormat)
+		text = strings.TrimSuffix(text, "-0000")
+		text = strings.TrimSuffix(text, ".000")
+		text = strings.TrimSuffix(text, ":00")
+
+		err = stmt.BindText64(ordinal, text)
+	default:
+		return false, nil
+	}
+
+	if err != nil {
+		return true, fmt.Errorf("bind %v: %w: %v", ordinal, err, db.ErrMsg())
+	}
+
+	return true, nil
+}
+
+// scanAll reads the columns of the current row into dest, following the conversions of sqlitepool.
+func scanAll(stmt sqliteh.Stmt, dest ...any) error {
+	for i, d := range dest {
+		if scanner, ok := d.(sql.Scanner); ok {
+			var value any
+
+			switch stmt.ColumnType(i) {
+			case sqliteh.SQLITE_INTEGER:
+				value = stmt.ColumnInt64(i)
+			case sqliteh.SQLITE_FLOAT:
+				value = stmt.ColumnDouble(i)
+			case sqliteh.SQLITE_TEXT:
+				value = stmt.ColumnText(i)
+			case sqliteh.SQLITE_BLOB:
+				value = append([]byte(nil), stmt.ColumnBlob(i)...)
+			case sqliteh.SQLITE_NULL:
+				value = nil
+			}
+
+			if err := scanner.Scan(value); err != nil {
+				return fmt.Errorf("scan %v: %w", i, err)
+			}
+
+			continue
+		}
+
+		val := reflect.ValueOf(d)
+		if val.Kind() != reflect.Pointer || val.IsNil() {
+			return fmt.Errorf("%w: scan %v: %T is not a pointer", cuttle.ErrUnsupportedType, i, d)
+		}
+
+		elem := val.Elem()
+
+		switch elem.Kind() {
+		case reflect.Slice:
+			if elem.Type().Elem().Kind() != reflect.Uint8 {
+				return fmt.Errorf("%w: scan %v: %T", cuttle.ErrUnsupportedType, i, d)
+			}
+
+			elem.SetBytes(append([]byte(nil), stmt.ColumnBlob(i)...))
+		case reflect.Bool:
+			elem.SetBool(stmt.ColumnInt64(i) != 0)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			elem.SetInt(stmt.ColumnInt64(i))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			elem.SetUint(uint64(stmt.ColumnInt64(i)))
+		case reflect.Float32, reflect.Float64:
+			elem.SetFloat(stmt.ColumnDouble(i))
+		case reflect.String:
+			elem.SetString(stmt.ColumnText(i))
+		default:
+			return fmt.Errorf("%w: scan %v: %T", cuttle.ErrUnsupportedType, i, d)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/csnewman/cuttle"
+	"github.com/tailscale/sqlite/sqliteh"
+)
+
+// copyEscaper escapes text values using the text format of the Postgres COPY command.
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+func (r *RTx) CopyTo(ctx context.Context, query string, w io.Writer) (int64, error) {
+	return copyTo(ctx, r.tx.DB(), query, w)
+}
+
+func (w *WTx) CopyTo(ctx context.Context, query string, wr io.Writer) (int64, error) {
+	return copyTo(ctx, w.tx.DB(), query, wr)
+}
+
+// copyTo writes the rows returned by query to w using the text format of the Postgres COPY command, as SQLite has no
+// equivalent. Each row is written on its own line, with values separated by tabs and NULL written as \N.
+func copyTo(ctx context.Context, db sqliteh.DB, query string, w io.Writer) (int64, error) {
+	// The statement is prepared without caching, as the query may only be used once
+	stmt, _, err := db.Prepare(query, 0)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", err, db.ErrMsg())
+	}
+
+	defer stmt.Finalize()
+
+	bw := bufio.NewWriter(w)
+
+	var n int64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		row, err := stmt.Step(nil)
+		if err != nil {
+			return n, fmt.Errorf("%w: %v", err, db.ErrMsg())
+		}
+
+		if !row {
+			break
+		}
+
+		for i := range stmt.ColumnCount() {
+			if i > 0 {
+				_ = bw.WriteByte('\t')
+			}
+
+			switch stmt.ColumnType(i) {
+			case sqliteh.SQLITE_NULL:
+				_, _ = bw.WriteString(`\N`)
+			case sqliteh.SQLITE_BLOB:
+				_, _ = bw.WriteString(`\\x` + hex.EncodeToString(stmt.ColumnBlob(i)))
+			default:
+				_, _ = copyEscaper.WriteString(bw, stmt.ColumnText(i))
+			}
+		}
+
+		if err := bw.WriteByte('\n'); err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, bw.Flush()
+}
+
+// CopyFrom inserts each row of src using a single prepared statement, as SQLite has no bulk loading protocol.
+func (w *WTx) CopyFrom(ctx context.Context, table string, columns []string, src cuttle.CopyFromSource) (int64, error) {
+	stmt := copyFromStmt(table, columns)
+
+	var total int64
+
+	for src.Next() {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		values, err := src.Values()
+		if err != nil {
+			return total, err
+		}
+
+		res, err := exec(w.cache, stmt, values)
+		if err != nil {
+			return total, err
+		}
+
+		total += res
+	}
+
+	return total, src.Err()
+}
+
+func (w *WTx) CopyFromFunc(
+	ctx context.Context,
+	handler cuttle.TxFunc[cuttle.Exec],
+	table string,
+	columns []string,
+	src cuttle.CopyFromSource,
+) error {
+	res, err := w.CopyFrom(ctx, table, columns, src)
+	if err != nil {
+		return err
+	}
+
+	return handler(ctx, &Exec{rowsAffected: res})
+}
+
+// copyFromStmt returns the insert statement for a single row of a bulk insert.
+func copyFromStmt(table string, columns []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("INSERT INTO ")
+
+	for i, part := range strings.Split(table, ".") {
+		if i > 0 {
+			sb.WriteString(".")
+		}
+
+		sb.WriteString(quoteIdent(part))
+	}
+
+	sb.WriteString(" (")
+
+	for i, column := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(quoteIdent(column))
+	}
+
+	sb.WriteString(") VALUES (")
+
+	for i := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		fmt.Fprintf(&sb, "?%v", i+1)
+	}
+
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
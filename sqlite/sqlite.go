@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/csnewman/cuttle"
 	"github.com/tailscale/sqlite/sqliteh"
@@ -12,11 +13,34 @@ import (
 
 var _ cuttle.DB = (*DB)(nil)
 
+var errRowScanned = errors.New("row already scanned")
+
 type DB struct {
-	pool *sqlitepool.Pool
+	pool  *sqlitepool.Pool
+	opts  Options
+	stats stmtCacheStats
+
+	mu     sync.Mutex
+	caches map[sqliteh.DB]*stmtCache
+}
+
+type Options struct {
+	// StmtCacheSize is the number of prepared statements cached by each connection, defaulting to
+	// DefaultStmtCacheSize. Caching is disabled when negative.
+	StmtCacheSize int
+	// SkipStmtCache reports whether a statement should be prepared for a single use instead of being cached, such as
+	// for statements that are rarely executed.
+	SkipStmtCache func(stmt string) bool
+	// CacheListStmts caches statements expanded for cuttle.List arguments. They are otherwise prepared for a single
+	// use, as each length of list produces a different statement which would evict the statements of other queries.
+	CacheListStmts bool
 }
 
 func Open(filename string, poolSize int) (*DB, error) {
+	return OpenWithOptions(filename, poolSize, Options{})
+}
+
+func OpenWithOptions(filename string, poolSize int, opts Options) (*DB, error) {
 	pool, err := sqlitepool.NewPool(filename, poolSize, func(db sqliteh.DB) error {
 		return nil
 	}, nil)
@@ -24,7 +48,49 @@ func Open(filename string, poolSize int) (*DB, error) {
 		return nil, err
 	}
 
-	return &DB{pool: pool}, nil
+	return &DB{
+		pool:   pool,
+		opts:   opts,
+		caches: make(map[sqliteh.DB]*stmtCache),
+	}, nil
+}
+
+// Close finalizes the cached statements of every connection and closes the pool. Transactions must not be in progress.
+func (d *DB) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for db, cache := range d.caches {
+		cache.close()
+		delete(d.caches, db)
+	}
+
+	return d.pool.Close()
+}
+
+// StmtCacheStats returns the combined statistics of the prepared statement caches of every connection.
+func (d *DB) StmtCacheStats() StmtCacheStats {
+	return StmtCacheStats{
+		Hits:      d.stats.hits.Load(),
+		Misses:    d.stats.misses.Load(),
+		Evictions: d.stats.evictions.Load(),
+		Uncached:  d.stats.uncached.Load(),
+		Size:      d.stats.size.Load(),
+	}
+}
+
+// stmtCache returns the prepared statement cache of a connection.
+func (d *DB) stmtCache(db sqliteh.DB) *stmtCache {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cache, ok := d.caches[db]
+	if !ok {
+		cache = newStmtCache(db, d.opts, &d.stats)
+		d.caches[db] = cache
+	}
+
+	return cache
 }
 
 func (d *DB) ExecFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Exec], stmt string, args ...any) error {
@@ -38,6 +104,18 @@ func (d *DB) ExecFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Exec], s
 	})
 }
 
+func (d *DB) CopyFromFunc(
+	ctx context.Context,
+	handler cuttle.TxFunc[cuttle.Exec],
+	table string,
+	columns []string,
+	src cuttle.CopyFromSource,
+) error {
+	return d.WTx(ctx, func(ctx context.Context, tx cuttle.WTx) error {
+		return tx.CopyFromFunc(ctx, handler, table, columns, src)
+	})
+}
+
 func (d *DB) QueryFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Rows], stmt string, args ...any) error {
 	return d.RTx(ctx, func(ctx context.Context, tx cuttle.RTx) error {
 		res, err := tx.Query(ctx, stmt, args...)
@@ -80,7 +158,7 @@ func (d *DB) RTx(ctx context.Context, f cuttle.RTxFunc) error {
 
 	defer tx.Rollback()
 
-	return f(ctx, &RTx{tx: tx})
+	return f(ctx, &RTx{tx: tx, cache: d.stmtCache(tx.DB())})
 }
 
 func (d *DB) WTx(ctx context.Context, f cuttle.WTxFunc) error {
@@ -91,7 +169,7 @@ func (d *DB) WTx(ctx context.Context, f cuttle.WTxFunc) error {
 
 	defer tx.Rollback()
 
-	if err := f(ctx, &WTx{tx: tx}); err != nil {
+	if err := f(ctx, &WTx{tx: tx, cache: d.stmtCache(tx.DB())}); err != nil {
 		return fmt.Errorf("error during tx: %w", err)
 	}
 
@@ -107,28 +185,37 @@ func (d *DB) Dialect() cuttle.Dialect {
 }
 
 type Rows struct {
-	res *sqlitepool.Rows
+	stmt *stmt
 }
 
 func (r *Rows) Close() error {
-	err := r.res.Close()
-	if err != nil {
-		return err
+	if r.stmt == nil {
+		return nil
 	}
 
-	return errors.Join(err, r.res.Err())
+	err := r.stmt.release()
+	r.stmt = nil
+
+	return err
 }
 
 func (r *Rows) Next(dest ...any) (bool, error) {
-	if r.res.Err() != nil {
-		return false, r.Close()
+	if r.stmt == nil {
+		return false, nil
+	}
+
+	row, err := r.stmt.stmt.Step(nil)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", err, r.stmt.stmt.DBHandle().ErrMsg())
+
+		return false, errors.Join(err, r.Close())
 	}
 
-	if !r.res.Next() {
+	if !row {
 		return false, r.Close()
 	}
 
-	if err := r.res.Scan(dest...); err != nil {
+	if err := scanAll(r.stmt.stmt, dest...); err != nil {
 		_ = r.Close()
 
 		return false, err
@@ -137,12 +224,22 @@ func (r *Rows) Next(dest ...any) (bool, error) {
 	return true, nil
 }
 
+// Row is a row that has been stepped to, releasing the statement once scanned.
 type Row struct {
-	row *sqlitepool.Row
+	stmt *stmt
 }
 
 func (r *Row) Scan(dest ...any) error {
-	return r.row.Scan(dest...)
+	if r.stmt == nil {
+		return errRowScanned
+	}
+
+	err := scanAll(r.stmt.stmt, dest...)
+
+	_ = r.stmt.release()
+	r.stmt = nil
+
+	return err
 }
 
 type Exec struct {
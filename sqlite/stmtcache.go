@@ -0,0 +1,193 @@
+package sqlite
+
+import (
+	"container/list"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/tailscale/sqlite/sqliteh"
+)
+
+// DefaultStmtCacheSize is the number of prepared statements cached by each connection when Options.StmtCacheSize is
+// zero.
+const DefaultStmtCacheSize = 128
+
+// StmtCacheStats reports the use of the prepared statement caches of every connection.
+type StmtCacheStats struct {
+	// Hits is the number of statements reused from a cache.
+	Hits uint64
+	// Misses is the number of statements prepared and added to a cache.
+	Misses uint64
+	// Evictions is the number of statements removed from a cache to make room for another.
+	Evictions uint64
+	// Uncached is the number of statements prepared for a single use, as they were skipped, caching is disabled, or the
+	// cached statement was already in use.
+	Uncached uint64
+	// Size is the number of statements currently cached.
+	Size int64
+}
+
+type stmtCacheStats struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+	uncached  atomic.Uint64
+	size      atomic.Int64
+}
+
+// stmtCache is a least recently used cache of the prepared statements of a connection. A connection is only used by
+// one transaction at a time, so the cache itself requires no locking.
+//
+// sqlitepool caches the statements of its connections too, but its cache is unbounded, hands out a statement that is
+// already being stepped by an open Rows, and panics when a statement fails to prepare. Its cache is also tied to its
+// query helpers, whose binding and scanning is unexported, so both are reimplemented here.
+type stmtCache struct {
+	db        sqliteh.DB
+	size      int
+	skip      func(query string) bool
+	cacheList bool
+	stats     *stmtCacheStats
+	// lru contains the cached statements, with the most recently used at the front
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+func newStmtCache(db sqliteh.DB, opts Options, stats *stmtCacheStats) *stmtCache {
+	size := opts.StmtCacheSize
+	if size == 0 {
+		size = DefaultStmtCacheSize
+	}
+
+	return &stmtCache{
+		db:        db,
+		size:      size,
+		skip:      opts.SkipStmtCache,
+		cacheList: opts.CacheListStmts,
+		stats:     stats,
+		lru:       list.New(),
+		entries:   make(map[string]*list.Element),
+	}
+}
+
+// stmt is a prepared statement, which must be released once no longer in use.
+type stmt struct {
+	query string
+	stmt  sqliteh.Stmt
+	// cache is nil for statements prepared for a single use
+	cache *stmtCache
+	inUse bool
+	// evicted indicates the statement was evicted while in use, so must be finalized once released
+	evicted bool
+}
+
+// prepare returns a prepared statement for the query, reusing a cached statement where possible. Queries expanded for
+// list arguments are only cached when enabled by Options.CacheListStmts.
+func (c *stmtCache) prepare(query string, expanded bool) (*stmt, error) {
+	if c.size < 0 || (expanded && !c.cacheList) || (c.skip != nil && c.skip(query)) {
+		return c.prepareOnce(query)
+	}
+
+	if el, ok := c.entries[query]; ok {
+		s := el.Value.(*stmt) //nolint:forcetypeassert
+
+		// A statement can only be stepped by one user at a time, such as when iterating rows of the same query
+		if s.inUse {
+			return c.prepareOnce(query)
+		}
+
+		c.stats.hits.Add(1)
+		c.lru.MoveToFront(el)
+
+		s.inUse = true
+
+		return s, nil
+	}
+
+	prepared, _, err := c.db.Prepare(query, sqliteh.SQLITE_PREPARE_PERSISTENT)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", err, c.db.ErrMsg())
+	}
+
+	c.stats.misses.Add(1)
+	c.stats.size.Add(1)
+
+	s := &stmt{
+		query: query,
+		stmt:  prepared,
+		cache: c,
+		inUse: true,
+	}
+
+	c.entries[query] = c.lru.PushFront(s)
+
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+
+	return s, nil
+}
+
+func (c *stmtCache) prepareOnce(query string) (*stmt, error) {
+	prepared, _, err := c.db.Prepare(query, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", err, c.db.ErrMsg())
+	}
+
+	c.stats.uncached.Add(1)
+
+	return &stmt{
+		query: query,
+		stmt:  prepared,
+		inUse: true,
+	}, nil
+}
+
+func (c *stmtCache) evict(el *list.Element) {
+	s := c.lru.Remove(el).(*stmt) //nolint:forcetypeassert
+	delete(c.entries, s.query)
+
+	c.stats.evictions.Add(1)
+	c.stats.size.Add(-1)
+
+	if s.inUse {
+		s.evicted = true
+
+		return
+	}
+
+	s.stmt.Finalize()
+}
+
+// close finalizes every cached statement.
+func (c *stmtCache) close() {
+	for c.lru.Len() > 0 {
+		s := c.lru.Remove(c.lru.Front()).(*stmt) //nolint:forcetypeassert
+		delete(c.entries, s.query)
+
+		c.stats.size.Add(-1)
+
+		if s.inUse {
+			s.evicted = true
+
+			continue
+		}
+
+		s.stmt.Finalize()
+	}
+}
+
+// release resets the statement, returning it to the cache or finalizing it if it is not cached.
+func (s *stmt) release() error {
+	_, err := s.stmt.ResetAndClear()
+	if err != nil {
+		err = fmt.Errorf("%w: %v", err, s.stmt.DBHandle().ErrMsg())
+	}
+
+	if s.cache == nil || s.evicted {
+		s.stmt.Finalize()
+	}
+
+	s.inUse = false
+
+	return err
+}
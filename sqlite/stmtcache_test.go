@@ -0,0 +1,196 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/csnewman/cuttle"
+)
+
+const (
+	selectA    = "SELECT 1 UNION ALL SELECT 2"
+	selectB    = "SELECT 3"
+	selectList = "SELECT value FROM json_each('[1,2,3]') WHERE value IN (?1)"
+)
+
+func openTestDB(t *testing.T, opts Options) *DB {
+	t.Helper()
+
+	db, err := OpenWithOptions(filepath.Join(t.TempDir(), "test.db"), 2, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return db
+}
+
+// collect reads the remaining integer values of rows.
+func collect(t *testing.T, rows cuttle.Rows) []int64 {
+	t.Helper()
+
+	var values []int64
+
+	for {
+		var v int64
+
+		ok, err := rows.Next(&v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !ok {
+			return values
+		}
+
+		values = append(values, v)
+	}
+}
+
+func TestStmtCacheInUse(t *testing.T) {
+	db := openTestDB(t, Options{})
+
+	err := db.RTx(context.Background(), func(ctx context.Context, tx cuttle.RTx) error {
+		first, err := tx.Query(ctx, selectA)
+		if err != nil {
+			return err
+		}
+
+		// The cached statement is being stepped by first, so the second query must use its own statement
+		second, err := tx.Query(ctx, selectA)
+		if err != nil {
+			return err
+		}
+
+		if got := collect(t, second); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+			t.Errorf("second rows = %v, want [1 2]", got)
+		}
+
+		if got := collect(t, first); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+			t.Errorf("first rows = %v, want [1 2]", got)
+		}
+
+		// Once released, the cached statement is reused
+		third, err := tx.Query(ctx, selectA)
+		if err != nil {
+			return err
+		}
+
+		if got := collect(t, third); len(got) != 2 {
+			t.Errorf("third rows = %v, want [1 2]", got)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := StmtCacheStats{Hits: 1, Misses: 1, Uncached: 1, Size: 1}
+	if got := db.StmtCacheStats(); got != want {
+		t.Errorf("StmtCacheStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStmtCacheEvictInUse(t *testing.T) {
+	db := openTestDB(t, Options{StmtCacheSize: 1})
+
+	err := db.RTx(context.Background(), func(ctx context.Context, tx cuttle.RTx) error {
+		rows, err := tx.Query(ctx, selectA)
+		if err != nil {
+			return err
+		}
+
+		var v int64
+
+		if ok, err := rows.Next(&v); err != nil || !ok || v != 1 {
+			t.Fatalf("Next() = %v, %v, %v, want 1", ok, v, err)
+		}
+
+		inUse := rows.(*Rows).stmt //nolint:forcetypeassert
+
+		// Caching another statement evicts the statement still being stepped by rows
+		row, err := tx.QueryRow(ctx, selectB)
+		if err != nil {
+			return err
+		}
+
+		if err := row.Scan(&v); err != nil || v != 3 {
+			t.Errorf("Scan() = %v, %v, want 3", v, err)
+		}
+
+		if !inUse.evicted {
+			t.Errorf("statement in use was not marked as evicted")
+		}
+
+		// The evicted statement remains usable until released
+		if got := collect(t, rows); len(got) != 1 || got[0] != 2 {
+			t.Errorf("remaining rows = %v, want [2]", got)
+		}
+
+		// The evicted statement was finalized when released, so is prepared again
+		rows, err = tx.Query(ctx, selectA)
+		if err != nil {
+			return err
+		}
+
+		if got := collect(t, rows); len(got) != 2 {
+			t.Errorf("rows = %v, want [1 2]", got)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := StmtCacheStats{Misses: 3, Evictions: 2, Size: 1}
+	if got := db.StmtCacheStats(); got != want {
+		t.Errorf("StmtCacheStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStmtCacheLists(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want StmtCacheStats
+	}{
+		{name: "default", opts: Options{}, want: StmtCacheStats{Uncached: 2}},
+		{name: "cached", opts: Options{CacheListStmts: true}, want: StmtCacheStats{Hits: 1, Misses: 1, Size: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t, tt.opts)
+
+			err := db.RTx(context.Background(), func(ctx context.Context, tx cuttle.RTx) error {
+				for range 2 {
+					rows, err := tx.Query(ctx, selectList, cuttle.NewList([]int64{1, 3}))
+					if err != nil {
+						return err
+					}
+
+					if got := collect(t, rows); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+						t.Errorf("rows = %v, want [1 3]", got)
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := db.StmtCacheStats(); got != tt.want {
+				t.Errorf("StmtCacheStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
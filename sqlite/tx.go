@@ -2,6 +2,8 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 
 	"github.com/csnewman/cuttle"
 	"github.com/tailscale/sqlite/sqlitepool"
@@ -13,7 +15,8 @@ var (
 )
 
 type RTx struct {
-	tx *sqlitepool.Rx
+	tx    *sqlitepool.Rx
+	cache *stmtCache
 }
 
 func (r *RTx) QueryFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Rows], stmt string, args ...any) error {
@@ -26,12 +29,7 @@ func (r *RTx) QueryFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Rows],
 }
 
 func (r *RTx) Query(_ context.Context, stmt string, args ...any) (cuttle.Rows, error) {
-	res, err := r.tx.Query(stmt, args...)
-	if err != nil {
-		return nil, err
-	}
-
-	return &Rows{res: res}, nil
+	return query(r.cache, stmt, args)
 }
 
 func (r *RTx) QueryRowFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Row], stmt string, args ...any) error {
@@ -44,12 +42,7 @@ func (r *RTx) QueryRowFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Row
 }
 
 func (r *RTx) QueryRow(_ context.Context, stmt string, args ...any) (cuttle.Row, error) {
-	row := r.tx.QueryRow(stmt, args...)
-	if err := row.Err(); err != nil {
-		return nil, err
-	}
-
-	return &Row{row: row}, nil
+	return queryRow(r.cache, stmt, args)
 }
 
 func (r *RTx) DispatchBatchR(ctx context.Context, b *cuttle.BatchR) error {
@@ -75,7 +68,8 @@ func (r *RTx) DispatchBatchR(ctx context.Context, b *cuttle.BatchR) error {
 }
 
 type WTx struct {
-	tx *sqlitepool.Tx
+	tx    *sqlitepool.Tx
+	cache *stmtCache
 }
 
 func (w *WTx) QueryFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Rows], stmt string, args ...any) error {
@@ -88,12 +82,7 @@ func (w *WTx) QueryFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Rows],
 }
 
 func (w *WTx) Query(_ context.Context, stmt string, args ...any) (cuttle.Rows, error) {
-	res, err := w.tx.Query(stmt, args...)
-	if err != nil {
-		return nil, err
-	}
-
-	return &Rows{res: res}, nil
+	return query(w.cache, stmt, args)
 }
 
 func (w *WTx) QueryRowFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Row], stmt string, args ...any) error {
@@ -106,12 +95,7 @@ func (w *WTx) QueryRowFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Row
 }
 
 func (w *WTx) QueryRow(_ context.Context, stmt string, args ...any) (cuttle.Row, error) {
-	row := w.tx.QueryRow(stmt, args...)
-	if err := row.Err(); err != nil {
-		return nil, err
-	}
-
-	return &Row{row: row}, nil
+	return queryRow(w.cache, stmt, args)
 }
 
 func (w *WTx) ExecFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Exec], stmt string, args ...any) error {
@@ -124,7 +108,7 @@ func (w *WTx) ExecFunc(ctx context.Context, handler cuttle.TxFunc[cuttle.Exec],
 }
 
 func (w *WTx) Exec(_ context.Context, stmt string, args ...any) (cuttle.Exec, error) {
-	res, err := w.tx.ExecRes(stmt, args...)
+	res, err := exec(w.cache, stmt, args)
 	if err != nil {
 		return nil, err
 	}
@@ -187,3 +171,73 @@ func (w *WTx) DispatchBatchRW(ctx context.Context, b *cuttle.BatchRW) error {
 
 	return nil
 }
+
+// prepare expands and binds args to a statement from the cache. The statement must be released once no longer in use.
+func prepare(cache *stmtCache, query string, args []any) (*stmt, error) {
+	expanded, args, err := cuttle.ExpandArgs(cuttle.DialectSQLite, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := cache.prepare(expanded, expanded != query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bindAll(cache.db, s.stmt, args); err != nil {
+		_ = s.release()
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func query(cache *stmtCache, query string, args []any) (*Rows, error) {
+	s, err := prepare(cache, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rows{stmt: s}, nil
+}
+
+func queryRow(cache *stmtCache, query string, args []any) (*Row, error) {
+	s, err := prepare(cache, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := s.stmt.Step(nil)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", err, cache.db.ErrMsg())
+		_ = s.release()
+
+		return nil, err
+	}
+
+	if !row {
+		_ = s.release()
+
+		return nil, sql.ErrNoRows
+	}
+
+	return &Row{stmt: s}, nil
+}
+
+func exec(cache *stmtCache, query string, args []any) (int64, error) {
+	s, err := prepare(cache, query, args)
+	if err != nil {
+		return 0, err
+	}
+
+	_, _, rowsAffected, _, err := s.stmt.StepResult()
+	if err != nil {
+		err = fmt.Errorf("%w: %v", err, cache.db.ErrMsg())
+		_ = s.release()
+
+		return 0, err
+	}
+
+	return rowsAffected, s.release()
+}